@@ -2,18 +2,39 @@ package main
 
 import (
 	"context"
+	"os/signal"
+	"sync"
+	"syscall"
 
+	"github.com/Danny-Dasilva/CycleTLS/cycletls"
+	"github.com/olafszymanski/int-ladbrokes/internal/auth"
+	"github.com/olafszymanski/int-ladbrokes/internal/broker"
 	"github.com/olafszymanski/int-ladbrokes/internal/client"
 	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/egress"
+	"github.com/olafszymanski/int-ladbrokes/internal/election"
+	"github.com/olafszymanski/int-ladbrokes/internal/gateway"
+	"github.com/olafszymanski/int-ladbrokes/internal/limiter"
+	"github.com/olafszymanski/int-ladbrokes/internal/metrics"
 	"github.com/olafszymanski/int-ladbrokes/internal/poller"
+	"github.com/olafszymanski/int-ladbrokes/internal/proxy"
+	"github.com/olafszymanski/int-ladbrokes/internal/server"
+	"github.com/olafszymanski/int-ladbrokes/internal/shard"
 	"github.com/olafszymanski/int-ladbrokes/internal/storage"
 	"github.com/olafszymanski/int-sdk/http"
 	"github.com/olafszymanski/int-sdk/integration/pb"
-	"github.com/olafszymanski/int-sdk/integration/server"
 	sdkStorage "github.com/olafszymanski/int-sdk/storage"
+	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
+// sports polled by the service
+var sportTypes = []pb.SportType{
+	pb.SportType_BASKETBALL,
+}
+
 func main() {
 	logrus.Info("starting service...")
 
@@ -29,7 +50,9 @@ func main() {
 		logrus.SetLevel(level)
 	}
 
-	ctx := context.Background()
+	// the service is stopped once interrupted or terminated, the waits for the upstream budget are cut short as well
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	r, err := sdkStorage.NewRedisStorage(ctx, cfg.Storage.Address, cfg.Storage.Password)
 	if err != nil {
@@ -37,20 +60,112 @@ func main() {
 	}
 	defer r.Close()
 
-	s := storage.NewStorage(r)
+	rc, err := broker.NewRedisClient(ctx, cfg.Storage.Address, cfg.Storage.Password)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create redis client")
+	}
+	defer rc.Close()
 
-	httpCl := http.NewClient()
+	s := storage.NewStorage(r, broker.NewBroker(rc), rc, cfg.Cache.InvalidationInterval)
+
+	var sw egress.Switcher
+	if cfg.Egress.SwitchWebhook != "" {
+		sw = egress.NewWebhookSwitcher(cfg.Egress.SwitchWebhook)
+	}
+	// the blocked requests are not sent until the cooldown passes
+	var doer http.Doer = egress.NewGuard(cfg, http.NewClient(), sw)
+	if len(cfg.Proxy.Proxies) > 0 {
+		tls := cycletls.Init()
+		doer = proxy.NewPool(cfg, func(proxyUrl string) http.Doer {
+			// every proxy is guarded on its own, so that a ban of one of them only ejects it from the pool
+			return egress.NewGuard(cfg, proxy.NewClient(&tls, proxyUrl), sw)
+		})
+	}
+	// every request sent upstream shares the budgets of the limiter
+	httpCl := limiter.NewLimiter(ctx, cfg, doer)
 
 	go func() {
-		p, err := poller.NewPoller(cfg, httpCl, s)
-		if err != nil {
-			logrus.WithError(err).Fatal("failed to create poller")
+		if err := metrics.Start(cfg.App.MetricsPort); err != nil {
+			logrus.WithError(err).Fatal("failed to serve metrics")
 		}
-		if err = p.Run(ctx, pb.SportType_BASKETBALL); err != nil {
-			logrus.WithError(err).Fatal("failed to run poller")
+	}()
+
+	logrus.WithFields(logrus.Fields{
+		"role":        cfg.App.Role,
+		"instance_id": cfg.App.InstanceId,
+	}).Info("running service")
+
+	switch cfg.App.Role {
+	case config.PollerRole:
+		runPoller(ctx, cfg, httpCl, s, rc)
+	case config.APIRole:
+		runAPI(ctx, cfg, httpCl, s)
+	default:
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runPoller(ctx, cfg, httpCl, s, rc)
+		}()
+		runAPI(ctx, cfg, httpCl, s)
+		// the leases and the memberships are released by the pollers before the storage is closed
+		wg.Wait()
+	}
+	logrus.Info("service stopped")
+}
+
+// runPoller polls every sport together with the other poller instances, the classes are sharded among them
+// and only the lease holder polls the classes themselves
+func runPoller(ctx context.Context, cfg *config.Config, httpCl http.Doer, s *storage.Storage, rc *redis.Client) {
+	p, err := poller.NewPoller(
+		cfg,
+		httpCl,
+		s,
+		election.NewElector(rc, cfg.App.InstanceId, cfg.Election.LeaseTTL),
+		shard.NewMembership(rc, cfg.App.InstanceId, cfg.Shard.MemberTTL),
+	)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create poller")
+	}
+
+	var wg sync.WaitGroup
+	for _, st := range sportTypes {
+		st := st
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := p.Run(ctx, st); err != nil && ctx.Err() == nil {
+				logrus.WithError(err).WithField("sport_type", st).Fatal("failed to run poller")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func runAPI(ctx context.Context, cfg *config.Config, httpCl http.Doer, s *storage.Storage) {
+	a := auth.NewAuthenticator(cfg)
+	tc, err := auth.NewTLSConfig(cfg)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create TLS config")
+	}
+
+	cl := client.NewClient(ctx, cfg, httpCl, s)
+	lcl := client.NewLadbrokesClient(cfg, s)
+
+	go func() {
+		if err := gateway.Start(ctx, cl, lcl, a, tc, cfg.App.HttpPort); err != nil {
+			logrus.WithError(err).Fatal("failed to serve gateway")
 		}
 	}()
 
-	cl := client.NewClient(cfg, httpCl, s)
-	server.Start(cl, cfg.App.Port)
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(a.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(a.StreamInterceptor()),
+	}
+	if tc != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tc)))
+	}
+	if err := server.Start(ctx, cl, lcl, cfg.App.Port, opts...); err != nil {
+		logrus.WithError(err).Fatal("failed to serve")
+	}
 }
@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/metrics"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	MetadataKey = "x-api-key"
+	HeaderKey   = "X-Api-Key"
+
+	anonymousConsumer = "anonymous"
+)
+
+// adminMethods are allowed to be called by the admin consumers only
+var adminMethods = map[string]struct{}{
+	"/ladbrokes.Ladbrokes/RefreshEvent": {},
+}
+
+// Authenticator authenticates the API consumers by their keys and limits the rate of their requests,
+// authentication is disabled if no keys are configured
+type Authenticator struct {
+	consumers map[string]string
+	limiters  map[string]*rate.Limiter
+	admins    map[string]struct{}
+}
+
+func NewAuthenticator(cfg *config.Config) *Authenticator {
+	a := &Authenticator{
+		consumers: cfg.Auth.Keys,
+		limiters:  make(map[string]*rate.Limiter, len(cfg.Auth.Keys)),
+		admins:    make(map[string]struct{}, len(cfg.Auth.AdminConsumers)),
+	}
+	for _, c := range cfg.Auth.AdminConsumers {
+		a.admins[c] = struct{}{}
+	}
+	for _, c := range cfg.Auth.Keys {
+		l := cfg.Auth.RateLimit
+		if cl, ok := cfg.Auth.ConsumersRateLimits[c]; ok {
+			l = cl
+		}
+		a.limiters[c] = rate.NewLimiter(rate.Limit(l), cfg.Auth.RateBurst)
+	}
+	return a
+}
+
+// Authenticate returns the consumer the key belongs to,
+// it fails with Unauthenticated if the key is unknown and with ResourceExhausted if the consumer exceeded its rate limit
+func (a *Authenticator) Authenticate(key string) (string, error) {
+	if len(a.consumers) == 0 {
+		return anonymousConsumer, nil
+	}
+
+	c, ok := a.consumers[key]
+	if !ok {
+		return anonymousConsumer, status.Error(codes.Unauthenticated, "missing or invalid api key")
+	}
+	if !a.limiters[c].Allow() {
+		return c, status.Errorf(codes.ResourceExhausted, "rate limit exceeded for consumer %s", c)
+	}
+	return c, nil
+}
+
+// Authorize checks whether the consumer is allowed to call the method, it fails with PermissionDenied
+// if the method is an admin one and the consumer is not an admin. The admin methods trigger the upstream requests
+// on demand, so they are denied to everyone if authentication is disabled
+func (a *Authenticator) Authorize(consumer, method string) error {
+	if _, ok := adminMethods[method]; !ok {
+		return nil
+	}
+	if len(a.consumers) == 0 {
+		return status.Errorf(codes.PermissionDenied, "%s requires authentication to be enabled", method)
+	}
+	if _, ok := a.admins[consumer]; !ok {
+		return status.Errorf(codes.PermissionDenied, "consumer %s is not allowed to call %s", consumer, method)
+	}
+	return nil
+}
+
+// Observe records the result of the consumer's request
+func (a *Authenticator) Observe(consumer, method string, err error) {
+	metrics.APIRequests.WithLabelValues(consumer, method, status.Code(err).String()).Inc()
+}
+
+func (a *Authenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		c, err := a.Authenticate(getMetadataKey(ctx))
+		if err == nil {
+			err = a.Authorize(c, info.FullMethod)
+		}
+		if err != nil {
+			a.Observe(c, info.FullMethod, err)
+			return nil, err
+		}
+		res, err := handler(ctx, req)
+		a.Observe(c, info.FullMethod, err)
+		return res, err
+	}
+}
+
+func (a *Authenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		c, err := a.Authenticate(getMetadataKey(ss.Context()))
+		if err == nil {
+			err = a.Authorize(c, info.FullMethod)
+		}
+		if err != nil {
+			a.Observe(c, info.FullMethod, err)
+			return err
+		}
+		err = handler(srv, ss)
+		a.Observe(c, info.FullMethod, err)
+		return err
+	}
+}
+
+// NewTLSConfig returns the server TLS config, or nil if TLS is not configured,
+// client certificates are required and verified (mTLS) if the client CA file is set
+func NewTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	if cfg.Auth.TLSCertFile == "" || cfg.Auth.TLSKeyFile == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.Auth.TLSCertFile, cfg.Auth.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+	tc := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.Auth.TLSClientCAFile != "" {
+		ca, err := os.ReadFile(cfg.Auth.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("failed to parse client CA")
+		}
+		tc.ClientCAs = pool
+		tc.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tc, nil
+}
+
+func getMetadataKey(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v := md.Get(MetadataKey); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
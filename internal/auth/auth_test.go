@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     map[string]string
+		key      string
+		consumer string
+		code     codes.Code
+	}{
+		{
+			name:     "valid key",
+			keys:     map[string]string{"key1": "bets", "key2": "dashboard"},
+			key:      "key2",
+			consumer: "dashboard",
+			code:     codes.OK,
+		},
+		{
+			name:     "invalid key",
+			keys:     map[string]string{"key1": "bets"},
+			key:      "key2",
+			consumer: anonymousConsumer,
+			code:     codes.Unauthenticated,
+		},
+		{
+			name:     "missing key",
+			keys:     map[string]string{"key1": "bets"},
+			consumer: anonymousConsumer,
+			code:     codes.Unauthenticated,
+		},
+		{
+			name:     "authentication disabled",
+			key:      "key1",
+			consumer: anonymousConsumer,
+			code:     codes.OK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Auth.Keys = test.keys
+			cfg.Auth.RateLimit = 1
+			cfg.Auth.RateBurst = 1
+
+			c, err := NewAuthenticator(cfg).Authenticate(test.key)
+			require.Equal(t, test.code, status.Code(err))
+			require.Equal(t, test.consumer, c)
+		})
+	}
+}
+
+func TestAuthenticateRateLimit(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Auth.Keys = map[string]string{"key1": "bets", "key2": "dashboard"}
+	cfg.Auth.RateLimit = 0.001
+	cfg.Auth.RateBurst = 2
+	a := NewAuthenticator(cfg)
+
+	for i := 0; i < 2; i++ {
+		_, err := a.Authenticate("key1")
+		require.NoError(t, err)
+	}
+	c, err := a.Authenticate("key1")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Equal(t, "bets", c)
+
+	// every consumer has its own limit
+	for i := 0; i < 2; i++ {
+		_, err := a.Authenticate("key2")
+		require.NoError(t, err)
+	}
+}
+
+func TestConsumersRateLimits(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Auth.Keys = map[string]string{"key1": "bets", "key2": "dashboard"}
+	cfg.Auth.RateLimit = 50
+	cfg.Auth.ConsumersRateLimits = map[string]float64{"dashboard": 5}
+	cfg.Auth.RateBurst = 100
+	a := NewAuthenticator(cfg)
+
+	require.Equal(t, rate.Limit(50), a.limiters["bets"].Limit())
+	require.Equal(t, rate.Limit(5), a.limiters["dashboard"].Limit())
+	require.Equal(t, 100, a.limiters["dashboard"].Burst())
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	const method = "/ladbrokes.Ladbrokes/GetEvent"
+
+	tests := []struct {
+		name     string
+		key      string
+		consumer string
+		method   string
+		called   bool
+		code     codes.Code
+	}{
+		{
+			name:     "authenticated",
+			key:      "key1",
+			consumer: "bets",
+			method:   method,
+			called:   true,
+			code:     codes.OK,
+		},
+		{
+			name:     "unauthenticated",
+			key:      "key2",
+			consumer: anonymousConsumer,
+			method:   method,
+			code:     codes.Unauthenticated,
+		},
+		{
+			name:     "unauthorized",
+			key:      "key1",
+			consumer: "bets",
+			method:   "/ladbrokes.Ladbrokes/RefreshEvent",
+			code:     codes.PermissionDenied,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Auth.Keys = map[string]string{"key1": "bets"}
+			cfg.Auth.RateLimit = 1
+			cfg.Auth.RateBurst = 1
+			var (
+				called   bool
+				ctx      = metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, test.key))
+				requests = metrics.APIRequests.WithLabelValues(test.consumer, test.method, test.code.String())
+				before   = testutil.ToFloat64(requests)
+			)
+
+			_, err := NewAuthenticator(cfg).UnaryInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: test.method}, func(ctx context.Context, req any) (any, error) {
+				called = true
+				return nil, nil
+			})
+			require.Equal(t, test.code, status.Code(err))
+			require.Equal(t, test.called, called)
+			// every request is recorded by its consumer, method and code
+			require.Equal(t, before+1, testutil.ToFloat64(requests))
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	const (
+		adminMethod = "/ladbrokes.Ladbrokes/RefreshEvent"
+		method      = "/ladbrokes.Ladbrokes/GetEvent"
+	)
+
+	tests := []struct {
+		name     string
+		keys     map[string]string
+		consumer string
+		method   string
+		code     codes.Code
+	}{
+		{
+			name:     "method",
+			keys:     map[string]string{"key1": "bets"},
+			consumer: "bets",
+			method:   method,
+			code:     codes.OK,
+		},
+		{
+			name:     "admin method",
+			keys:     map[string]string{"key1": "ops"},
+			consumer: "ops",
+			method:   adminMethod,
+			code:     codes.OK,
+		},
+		{
+			name:     "admin method denied",
+			keys:     map[string]string{"key1": "bets"},
+			consumer: "bets",
+			method:   adminMethod,
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:     "authentication disabled",
+			consumer: anonymousConsumer,
+			method:   method,
+			code:     codes.OK,
+		},
+		{
+			name:     "admin method with authentication disabled",
+			consumer: anonymousConsumer,
+			method:   adminMethod,
+			code:     codes.PermissionDenied,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Auth.Keys = test.keys
+			cfg.Auth.AdminConsumers = []string{"ops", anonymousConsumer}
+
+			err := NewAuthenticator(cfg).Authorize(test.consumer, test.method)
+			require.Equal(t, test.code, status.Code(err))
+		})
+	}
+}
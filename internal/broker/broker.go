@@ -0,0 +1,74 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrSubscriptionClosed = fmt.Errorf("subscription closed")
+
+// Broker fans messages out through Redis pub/sub, so every replica of the service receives them
+type Broker struct {
+	client *redis.Client
+}
+
+func NewBroker(client *redis.Client) *Broker {
+	return &Broker{
+		client: client,
+	}
+}
+
+func (b *Broker) Publish(ctx context.Context, channel string, message []byte) error {
+	return b.client.Publish(ctx, channel, message).Err()
+}
+
+func (b *Broker) Subscribe(ctx context.Context, channels ...string) (*Subscription, error) {
+	ps := b.client.Subscribe(ctx, channels...)
+	// waiting for the confirmation guarantees that no message published after returning is missed
+	if _, err := ps.Receive(ctx); err != nil {
+		ps.Close()
+		return nil, err
+	}
+	return &Subscription{
+		pubSub:  ps,
+		channel: ps.Channel(),
+	}, nil
+}
+
+type Subscription struct {
+	pubSub  *redis.PubSub
+	channel <-chan *redis.Message
+}
+
+// Receive blocks until a message is received or the context is done
+func (s *Subscription) Receive(ctx context.Context) ([]byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case msg, ok := <-s.channel:
+		if !ok {
+			return nil, ErrSubscriptionClosed
+		}
+		return []byte(msg.Payload), nil
+	}
+}
+
+func (s *Subscription) Close() error {
+	return s.pubSub.Close()
+}
+
+// NewRedisClient returns a client connected to Redis, it is shared by the broker, the storage and the leader election
+func NewRedisClient(ctx context.Context, address, password string) (*redis.Client, error) {
+	c := redis.NewClient(&redis.Options{
+		Addr:     address,
+		Password: password,
+		DB:       0,
+	})
+	if _, err := c.Ping(ctx).Result(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/metrics"
+	"github.com/olafszymanski/int-ladbrokes/internal/storage"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/sirupsen/logrus"
+)
+
+const resubscribeInterval = time.Second
+
+type cacheEntry struct {
+	lock     sync.RWMutex
+	events   []*pb.Event
+	storedAt time.Time
+	valid    bool
+}
+
+// eventsCache is a read-through cache of the stored events keyed by their hash (sport type and live or pre-match),
+// entries are invalidated by the change notifications published by the storage and expire after maxStaleness regardless
+type eventsCache struct {
+	lock         sync.Mutex
+	entries      map[string]*cacheEntry
+	storage      *storage.Storage
+	maxStaleness time.Duration
+}
+
+func newEventsCache(storage *storage.Storage, maxStaleness time.Duration) *eventsCache {
+	return &eventsCache{
+		lock:         sync.Mutex{},
+		entries:      make(map[string]*cacheEntry),
+		storage:      storage,
+		maxStaleness: maxStaleness,
+	}
+}
+
+func (c *eventsCache) getEvents(ctx context.Context, hash string) ([]*pb.Event, error) {
+	e := c.getEntry(hash)
+
+	e.lock.RLock()
+	if c.isFresh(e) {
+		evs := e.events
+		e.lock.RUnlock()
+		metrics.CacheRequests.WithLabelValues(hash, "hit").Inc()
+		return evs, nil
+	}
+	e.lock.RUnlock()
+
+	// only one reader loads the events, the rest of them wait for it and get the loaded ones
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if c.isFresh(e) {
+		metrics.CacheRequests.WithLabelValues(hash, "hit").Inc()
+		return e.events, nil
+	}
+	metrics.CacheRequests.WithLabelValues(hash, "miss").Inc()
+
+	// the lock is held while loading, thus an invalidation racing with the load is applied after it
+	evs, err := c.storage.GetEvents(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	e.events = evs
+	e.storedAt = time.Now()
+	e.valid = true
+	return evs, nil
+}
+
+func (c *eventsCache) invalidate(hash string) {
+	e := c.getEntry(hash)
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.valid = false
+	e.events = nil
+}
+
+// run invalidates the entries on every change notification until the context is done,
+// while the subscription is broken the entries are served until they get stale
+func (c *eventsCache) run(ctx context.Context) {
+	for {
+		if err := c.listen(ctx); err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Error("listening for events changes failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(resubscribeInterval):
+		}
+	}
+}
+
+func (c *eventsCache) listen(ctx context.Context) error {
+	sub, err := c.storage.SubscribeEventsChanges(ctx)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+
+	for {
+		hash, err := sub.Receive(ctx)
+		if err != nil {
+			return err
+		}
+		c.invalidate(string(hash))
+	}
+}
+
+func (c *eventsCache) getEntry(hash string) *cacheEntry {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	e, ok := c.entries[hash]
+	if !ok {
+		e = &cacheEntry{}
+		c.entries[hash] = e
+	}
+	return e
+}
+
+func (c *eventsCache) isFresh(entry *cacheEntry) bool {
+	return entry.valid && time.Since(entry.storedAt) < c.maxStaleness
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/olafszymanski/int-ladbrokes/internal/broker"
+	"github.com/olafszymanski/int-ladbrokes/internal/storage"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	sdkStorage "github.com/olafszymanski/int-sdk/storage"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+const testHash = "LIVE_EVENTS_BASKETBALL"
+
+func newTestStorage(t *testing.T) (*storage.Storage, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	r, err := sdkStorage.NewRedisStorage(context.Background(), mr.Addr(), "")
+	require.NoError(t, err)
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		r.Close()
+		rc.Close()
+	})
+	return storage.NewStorage(r, broker.NewBroker(rc), rc, 0), mr
+}
+
+func TestEventsCacheInvalidated(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		s, _        = newTestStorage(t)
+		c           = newEventsCache(s, time.Hour)
+	)
+	defer cancel()
+	require.NoError(t, s.StoreEvent(ctx, testHash, &pb.Event{ExternalId: "1"}))
+
+	evs, err := c.getEvents(ctx, testHash)
+	require.NoError(t, err)
+	require.Len(t, evs, 1)
+
+	// served from the cache until the change notification arrives
+	go c.run(ctx)
+	require.Eventually(t, func() bool {
+		require.NoError(t, s.StoreEvent(ctx, testHash, &pb.Event{ExternalId: "2"}))
+		evs, err := c.getEvents(ctx, testHash)
+		require.NoError(t, err)
+		return len(evs) == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestEventsCacheStale(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		s, _ = newTestStorage(t)
+		c    = newEventsCache(s, 50*time.Millisecond)
+	)
+	require.NoError(t, s.StoreEvent(ctx, testHash, &pb.Event{ExternalId: "1"}))
+
+	evs, err := c.getEvents(ctx, testHash)
+	require.NoError(t, err)
+	require.Len(t, evs, 1)
+
+	// nothing listens for the changes, thus the stored event is missed until the entry gets stale
+	require.NoError(t, s.StoreEvent(ctx, testHash, &pb.Event{ExternalId: "2"}))
+	evs, err = c.getEvents(ctx, testHash)
+	require.NoError(t, err)
+	require.Len(t, evs, 1)
+
+	time.Sleep(60 * time.Millisecond)
+	evs, err = c.getEvents(ctx, testHash)
+	require.NoError(t, err)
+	require.Len(t, evs, 2)
+}
+
+func TestEventsCacheInvalidate(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		s, _ = newTestStorage(t)
+		c    = newEventsCache(s, time.Hour)
+	)
+	require.NoError(t, s.StoreEvent(ctx, testHash, &pb.Event{ExternalId: "1"}))
+	_, err := c.getEvents(ctx, testHash)
+	require.NoError(t, err)
+
+	require.NoError(t, s.StoreEvent(ctx, testHash, &pb.Event{ExternalId: "2"}))
+	c.invalidate(testHash)
+	evs, err := c.getEvents(ctx, testHash)
+	require.NoError(t, err)
+	require.Len(t, evs, 2)
+}
@@ -2,43 +2,53 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/olafszymanski/int-ladbrokes/internal/config"
 	"github.com/olafszymanski/int-ladbrokes/internal/storage"
 	"github.com/olafszymanski/int-sdk/http"
 	"github.com/olafszymanski/int-sdk/integration/pb"
+	sdkStorage "github.com/olafszymanski/int-sdk/storage"
 )
 
 type client struct {
 	config     *config.Config
 	httpClient http.Doer
 	storage    *storage.Storage
+	cache      *eventsCache
 	pb.UnimplementedIntegrationServer
 }
 
-func NewClient(cfg *config.Config, httpClient http.Doer, storage *storage.Storage) pb.IntegrationServer {
+// NewClient creates the integration server, its cache is invalidated until the context is done
+func NewClient(ctx context.Context, cfg *config.Config, httpClient http.Doer, storage *storage.Storage) pb.IntegrationServer {
+	c := newEventsCache(storage, cfg.Cache.MaxStaleness)
+	go c.run(ctx)
+
 	return &client{
 		httpClient: httpClient,
 		config:     cfg,
 		storage:    storage,
+		cache:      c,
 	}
 }
 
 func (c *client) GetLive(ctx context.Context, request *pb.Request) (*pb.Response, error) {
-	evs, err := c.storage.GetEvents(ctx, fmt.Sprintf(config.LiveEventsStorageKey, request.SportType.String()))
-	if err != nil {
-		return nil, err
-	}
-	return &pb.Response{
-		Events: evs,
-	}, nil
+	return c.getEvents(ctx, fmt.Sprintf(config.LiveEventsStorageKey, request.SportType.String()))
 }
 
 func (c *client) GetPreMatch(ctx context.Context, request *pb.Request) (*pb.Response, error) {
-	evs, err := c.storage.GetEvents(ctx, fmt.Sprintf(config.PreMatchEventsStorageKey, request.SportType.String()))
+	return c.getEvents(ctx, fmt.Sprintf(config.PreMatchEventsStorageKey, request.SportType.String()))
+}
+
+func (c *client) getEvents(ctx context.Context, hash string) (*pb.Response, error) {
+	evs, err := c.cache.getEvents(ctx, hash)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, sdkStorage.ErrNotFound) {
+			setDataNotReady(ctx)
+			return &pb.Response{}, nil
+		}
+		return nil, toStatusError(err)
 	}
 	return &pb.Response{
 		Events: evs,
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/storage"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DataReadyMetadataKey is set to "false" in the response header if the requested events haven't been synced yet
+	DataReadyMetadataKey = "x-data-ready"
+
+	errorDomain = "int-ladbrokes"
+)
+
+// toStatusError maps the storage errors to the gRPC status ones, so that the consumers can tell which of them are worth retrying:
+// decoding failures become Internal and the rest of storage failures become Unavailable
+func toStatusError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	if errors.Is(err, storage.ErrDecode) {
+		return newStatusError(codes.Internal, "DECODE_FAILED", "failed to decode stored data", err)
+	}
+	return newStatusError(codes.Unavailable, "STORAGE_UNAVAILABLE", "storage unavailable", err)
+}
+
+func newStatusError(code codes.Code, reason, msg string, err error) error {
+	st, dErr := status.New(code, msg).WithDetails(&errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: errorDomain,
+		Metadata: map[string]string{
+			"error": err.Error(),
+		},
+	})
+	if dErr != nil {
+		return status.Error(code, msg)
+	}
+	return st.Err()
+}
+
+// setDataNotReady signals the consumer that the response is empty because the events haven't been synced yet
+func setDataNotReady(ctx context.Context) {
+	if err := grpc.SetHeader(ctx, metadata.Pairs(DataReadyMetadataKey, "false")); err != nil {
+		// the method was called outside of a gRPC or gateway request
+		logrus.WithError(err).Debug("failed to set data not ready header")
+	}
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/storage"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestToStatusError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedCode   codes.Code
+		expectedReason string
+	}{
+		{
+			name:         "status",
+			err:          status.Error(codes.NotFound, "event not found"),
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:         "canceled",
+			err:          fmt.Errorf("getting events failed: %w", context.Canceled),
+			expectedCode: codes.Canceled,
+		},
+		{
+			name:         "deadline exceeded",
+			err:          context.DeadlineExceeded,
+			expectedCode: codes.DeadlineExceeded,
+		},
+		{
+			name:           "decoding failed",
+			err:            fmt.Errorf("%w: unexpected end of JSON input", storage.ErrDecode),
+			expectedCode:   codes.Internal,
+			expectedReason: "DECODE_FAILED",
+		},
+		{
+			name:           "storage failed",
+			err:            errors.New("connection refused"),
+			expectedCode:   codes.Unavailable,
+			expectedReason: "STORAGE_UNAVAILABLE",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			st := status.Convert(toStatusError(test.err))
+			require.Equal(t, test.expectedCode, st.Code())
+			if test.expectedReason == "" {
+				require.Empty(t, st.Details())
+				return
+			}
+			require.Len(t, st.Details(), 1)
+			info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+			require.True(t, ok)
+			require.Equal(t, test.expectedReason, info.Reason)
+			require.Equal(t, errorDomain, info.Domain)
+			require.Equal(t, test.err.Error(), info.Metadata["error"])
+		})
+	}
+}
+
+// headerStream captures the headers set by the server methods
+type headerStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestGetEventsDataReady(t *testing.T) {
+	tests := []struct {
+		name              string
+		synced            bool
+		events            []*pb.Event
+		expectedEvents    int
+		expectedDataReady []string
+	}{
+		{
+			name:              "not synced",
+			expectedDataReady: []string{"false"},
+		},
+		{
+			name:   "synced empty",
+			synced: true,
+		},
+		{
+			name:           "stored",
+			events:         []*pb.Event{{ExternalId: "1"}},
+			expectedEvents: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				s, _ = newTestStorage(t)
+				c    = &client{cache: newEventsCache(s, time.Hour)}
+				hs   = &headerStream{}
+				ctx  = grpc.NewContextWithServerTransportStream(context.Background(), hs)
+			)
+			if test.synced {
+				require.NoError(t, s.MarkEventsSynced(ctx, testHash))
+			}
+			if len(test.events) > 0 {
+				require.NoError(t, s.StoreEvents(ctx, testHash, test.events))
+			}
+
+			res, err := c.getEvents(ctx, testHash)
+			require.NoError(t, err)
+			require.Len(t, res.Events, test.expectedEvents)
+			require.Equal(t, test.expectedDataReady, hs.header.Get(DataReadyMetadataKey))
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/storage"
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	sdkStorage "github.com/olafszymanski/int-sdk/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type ladbrokesClient struct {
+	config  *config.Config
+	storage *storage.Storage
+	ladbrokesPb.UnimplementedLadbrokesServer
+}
+
+func NewLadbrokesClient(cfg *config.Config, storage *storage.Storage) ladbrokesPb.LadbrokesServer {
+	return &ladbrokesClient{
+		config:  cfg,
+		storage: storage,
+	}
+}
+
+// GetEvent looks the event up in the live events first, as the event is there once started, then in the pre-match ones.
+// Both of them are read at once, so that the event being started in the meantime is found in either of them
+func (c *ladbrokesClient) GetEvent(ctx context.Context, request *ladbrokesPb.EventRequest) (*pb.Event, error) {
+	ev, err := c.storage.GetFirstEvent(ctx, []string{
+		getEventsHash(request.SportType, true),
+		getEventsHash(request.SportType, false),
+	}, request.ExternalId)
+	if err != nil {
+		if errors.Is(err, sdkStorage.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "event %s not found", request.ExternalId)
+		}
+		return nil, toStatusError(err)
+	}
+	return ev, nil
+}
+
+func (c *ladbrokesClient) GetClosingLine(ctx context.Context, request *ladbrokesPb.EventRequest) (*ladbrokesPb.ClosingLine, error) {
+	cl, err := c.storage.GetClosingLine(ctx, fmt.Sprintf(config.ClosingLineStorageKey, request.ExternalId))
+	if err != nil {
+		if errors.Is(err, sdkStorage.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "closing line for event %s not found", request.ExternalId)
+		}
+		return nil, toStatusError(err)
+	}
+	return cl, nil
+}
+
+// GetResults returns the results of the event recorded so far, they are complete once every outcome is settled
+func (c *ladbrokesClient) GetResults(ctx context.Context, request *ladbrokesPb.EventRequest) (*ladbrokesPb.Results, error) {
+	res, err := c.storage.GetResults(ctx, fmt.Sprintf(config.ResultsStorageKey, request.ExternalId))
+	if err != nil {
+		if errors.Is(err, sdkStorage.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "results for event %s not found", request.ExternalId)
+		}
+		return nil, toStatusError(err)
+	}
+	return res, nil
+}
+
+// RefreshEvent requests the live event to be refreshed from its full snapshot by the poller owning it,
+// it returns once the request is published, the refreshed markets are streamed as the deltas of the event
+func (c *ladbrokesClient) RefreshEvent(ctx context.Context, request *ladbrokesPb.EventRequest) (*emptypb.Empty, error) {
+	hash := getEventsHash(request.SportType, true)
+	if _, err := c.storage.GetEvent(ctx, hash, request.ExternalId); err != nil {
+		if errors.Is(err, sdkStorage.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "live event %s not found", request.ExternalId)
+		}
+		return nil, toStatusError(err)
+	}
+	if err := c.storage.PublishEventRefresh(ctx, hash, request.ExternalId); err != nil {
+		return nil, toStatusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (c *ladbrokesClient) StreamEvents(request *ladbrokesPb.StreamRequest, stream ladbrokesPb.Ladbrokes_StreamEventsServer) error {
+	var (
+		ctx  = stream.Context()
+		hash = getEventsHash(request.SportType, request.Live)
+	)
+
+	// subscribing before reading the snapshot guarantees no delta is missed in between
+	sub, err := c.storage.SubscribeDeltas(ctx, fmt.Sprintf(config.EventsDeltasChannelKey, hash))
+	if err != nil {
+		return toStatusError(err)
+	}
+	defer sub.Close()
+
+	evs, err := c.storage.GetEvents(ctx, hash)
+	if err != nil {
+		if !errors.Is(err, sdkStorage.ErrNotFound) {
+			return toStatusError(err)
+		}
+		setDataNotReady(ctx)
+	}
+	if err := stream.Send(&ladbrokesPb.StreamResponse{
+		Snapshot: evs,
+	}); err != nil {
+		return err
+	}
+
+	for {
+		ds, err := sub.Receive(ctx)
+		if err != nil {
+			return toStatusError(err)
+		}
+		if err := stream.Send(&ladbrokesPb.StreamResponse{
+			Deltas: ds.Deltas,
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+func getEventsHash(sportType pb.SportType, live bool) string {
+	if live {
+		return fmt.Sprintf(config.LiveEventsStorageKey, sportType)
+	}
+	return fmt.Sprintf(config.PreMatchEventsStorageKey, sportType)
+}
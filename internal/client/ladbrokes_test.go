@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetEvent(t *testing.T) {
+	tests := []struct {
+		name         string
+		live         *pb.Event
+		preMatch     *pb.Event
+		corrupted    bool
+		expected     *pb.Event
+		expectedCode codes.Code
+	}{
+		{
+			name:     "live",
+			live:     &pb.Event{ExternalId: "1", IsLive: true},
+			expected: &pb.Event{ExternalId: "1", IsLive: true},
+		},
+		{
+			name:     "pre-match",
+			preMatch: &pb.Event{ExternalId: "1"},
+			expected: &pb.Event{ExternalId: "1"},
+		},
+		{
+			name:     "live over pre-match",
+			live:     &pb.Event{ExternalId: "1", IsLive: true},
+			preMatch: &pb.Event{ExternalId: "1"},
+			expected: &pb.Event{ExternalId: "1", IsLive: true},
+		},
+		{
+			name:         "not found",
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:         "decoding failed",
+			corrupted:    true,
+			expectedCode: codes.Internal,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				ctx   = context.Background()
+				s, mr = newTestStorage(t)
+				c     = &ladbrokesClient{storage: s}
+			)
+			if test.live != nil {
+				require.NoError(t, s.StoreEvent(ctx, getEventsHash(pb.SportType_BASKETBALL, true), test.live))
+			}
+			if test.preMatch != nil {
+				require.NoError(t, s.StoreEvent(ctx, getEventsHash(pb.SportType_BASKETBALL, false), test.preMatch))
+			}
+			if test.corrupted {
+				mr.HSet(getEventsHash(pb.SportType_BASKETBALL, true), "1", "{")
+			}
+
+			ev, err := c.GetEvent(ctx, &ladbrokesPb.EventRequest{SportType: pb.SportType_BASKETBALL, ExternalId: "1"})
+			if test.expectedCode != codes.OK {
+				require.Equal(t, test.expectedCode, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), ev.String())
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/storage"
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	sdkStorage "github.com/olafszymanski/int-sdk/storage"
+)
+
+func (c *ladbrokesClient) QueryEvents(ctx context.Context, request *ladbrokesPb.QueryRequest) (*pb.Response, error) {
+	hash := getEventsHash(request.SportType, request.Live)
+
+	idx, err := c.storage.GetEventsIndex(ctx, hash)
+	if err != nil {
+		if errors.Is(err, sdkStorage.ErrNotFound) {
+			setDataNotReady(ctx)
+			return &pb.Response{}, nil
+		}
+		return nil, toStatusError(err)
+	}
+
+	evs, err := c.storage.GetEventsByIds(ctx, hash, filterEventsIds(idx, request))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	for i, e := range evs {
+		if len(request.MarketTypes) > 0 {
+			e.Markets = filterMarkets(e.Markets, request.MarketTypes)
+		}
+		if request.MarketsOnly {
+			evs[i] = &pb.Event{
+				ExternalId: e.ExternalId,
+				Markets:    e.Markets,
+			}
+		}
+	}
+	return &pb.Response{
+		Events: evs,
+	}, nil
+}
+
+// filterEventsIds returns the ids of the indexed events matching the request, ordered by their start time
+func filterEventsIds(index map[string]*storage.EventIndex, request *ladbrokesPb.QueryRequest) []string {
+	var (
+		lgs = toSet(request.Leagues)
+		ids = toSet(request.ExternalIds)
+		res = make([]string, 0)
+	)
+	for id, i := range index {
+		if len(ids) > 0 {
+			if _, ok := ids[id]; !ok {
+				continue
+			}
+		}
+		if len(lgs) > 0 {
+			if _, ok := lgs[i.League]; !ok {
+				continue
+			}
+		}
+		if request.StartTimeFrom != nil && i.StartTime < request.StartTimeFrom.AsTime().Unix() {
+			continue
+		}
+		if request.StartTimeTo != nil && i.StartTime >= request.StartTimeTo.AsTime().Unix() {
+			continue
+		}
+		if len(request.MarketTypes) > 0 && !hasAnyMarketType(i.MarketTypes, request.MarketTypes) {
+			continue
+		}
+		res = append(res, id)
+	}
+
+	sort.Slice(res, func(a, b int) bool {
+		if index[res[a]].StartTime == index[res[b]].StartTime {
+			return res[a] < res[b]
+		}
+		return index[res[a]].StartTime < index[res[b]].StartTime
+	})
+	return res
+}
+
+func filterMarkets(markets []*pb.Market, marketTypes []pb.MarketType) []*pb.Market {
+	mks := make([]*pb.Market, 0, len(markets))
+	for _, m := range markets {
+		for _, t := range marketTypes {
+			if m.Type == t {
+				mks = append(mks, m)
+				break
+			}
+		}
+	}
+	return mks
+}
+
+func hasAnyMarketType(marketTypes, wanted []pb.MarketType) bool {
+	for _, m := range marketTypes {
+		for _, w := range wanted {
+			if m == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/storage"
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestFilterEventsIds(t *testing.T) {
+	index := map[string]*storage.EventIndex{
+		"1": {League: "NBA", MarketTypes: []pb.MarketType{pb.MarketType_MONEYLINE}, StartTime: 300},
+		"2": {League: "NBA", MarketTypes: []pb.MarketType{pb.MarketType_TOTAL_POINTS}, StartTime: 100},
+		"3": {League: "Euroleague", MarketTypes: []pb.MarketType{pb.MarketType_MONEYLINE}, StartTime: 200},
+		"4": {League: "Euroleague", StartTime: 100},
+	}
+
+	tests := []struct {
+		name     string
+		request  *ladbrokesPb.QueryRequest
+		expected []string
+	}{
+		{
+			name:     "all, ordered by start time and id",
+			request:  &ladbrokesPb.QueryRequest{},
+			expected: []string{"2", "4", "3", "1"},
+		},
+		{
+			name:     "leagues",
+			request:  &ladbrokesPb.QueryRequest{Leagues: []string{"NBA"}},
+			expected: []string{"2", "1"},
+		},
+		{
+			name:     "external ids",
+			request:  &ladbrokesPb.QueryRequest{ExternalIds: []string{"1", "3", "5"}},
+			expected: []string{"3", "1"},
+		},
+		{
+			name: "start time range",
+			request: &ladbrokesPb.QueryRequest{
+				StartTimeFrom: timestamppb.New(time.Unix(100, 0)),
+				StartTimeTo:   timestamppb.New(time.Unix(300, 0)),
+			},
+			expected: []string{"2", "4", "3"},
+		},
+		{
+			name:     "market types",
+			request:  &ladbrokesPb.QueryRequest{MarketTypes: []pb.MarketType{pb.MarketType_MONEYLINE}},
+			expected: []string{"3", "1"},
+		},
+		{
+			name: "combined",
+			request: &ladbrokesPb.QueryRequest{
+				Leagues:     []string{"Euroleague"},
+				MarketTypes: []pb.MarketType{pb.MarketType_MONEYLINE},
+			},
+			expected: []string{"3"},
+		},
+		{
+			name:     "none matching",
+			request:  &ladbrokesPb.QueryRequest{Leagues: []string{"ACB"}},
+			expected: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, filterEventsIds(index, test.request))
+		})
+	}
+}
+
+func TestQueryEvents(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		s, _ = newTestStorage(t)
+		c    = &ladbrokesClient{storage: s}
+	)
+	require.NoError(t, s.StoreEvents(ctx, getEventsHash(pb.SportType_BASKETBALL, true), []*pb.Event{
+		{
+			ExternalId: "1",
+			League:     "NBA",
+			StartTime:  timestamppb.New(time.Unix(100, 0)),
+			Markets:    []*pb.Market{{ExternalId: "10", Type: pb.MarketType_MONEYLINE}, {ExternalId: "11", Type: pb.MarketType_TOTAL_POINTS}},
+		},
+		{
+			ExternalId: "2",
+			League:     "Euroleague",
+			StartTime:  timestamppb.New(time.Unix(100, 0)),
+		},
+	}))
+
+	res, err := c.QueryEvents(ctx, &ladbrokesPb.QueryRequest{
+		SportType:   pb.SportType_BASKETBALL,
+		Live:        true,
+		MarketTypes: []pb.MarketType{pb.MarketType_MONEYLINE},
+		MarketsOnly: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Events, 1)
+	require.Equal(t, "1", res.Events[0].ExternalId)
+	require.Empty(t, res.Events[0].League)
+	require.Len(t, res.Events[0].Markets, 1)
+	require.Equal(t, "10", res.Events[0].Markets[0].ExternalId)
+}
+
+func TestQueryEventsDataReady(t *testing.T) {
+	tests := []struct {
+		name              string
+		synced            bool
+		events            []*pb.Event
+		expectedEvents    int
+		expectedDataReady []string
+	}{
+		{
+			name:              "not synced",
+			expectedDataReady: []string{"false"},
+		},
+		{
+			name:   "synced empty",
+			synced: true,
+		},
+		{
+			name:           "stored",
+			events:         []*pb.Event{{ExternalId: "1"}},
+			expectedEvents: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				s, _ = newTestStorage(t)
+				c    = &ladbrokesClient{storage: s}
+				hs   = &headerStream{}
+				ctx  = grpc.NewContextWithServerTransportStream(context.Background(), hs)
+				hash = getEventsHash(pb.SportType_BASKETBALL, true)
+			)
+			if test.synced {
+				require.NoError(t, s.MarkEventsSynced(ctx, hash))
+			}
+			if len(test.events) > 0 {
+				require.NoError(t, s.StoreEvents(ctx, hash, test.events))
+			}
+
+			res, err := c.QueryEvents(ctx, &ladbrokesPb.QueryRequest{SportType: pb.SportType_BASKETBALL, Live: true})
+			require.NoError(t, err)
+			require.Len(t, res.Events, test.expectedEvents)
+			require.Equal(t, test.expectedDataReady, hs.header.Get(DataReadyMetadataKey))
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreMatchTimePeriods(t *testing.T) {
+	tests := []struct {
+		name          string
+		timePeriods   string
+		expected      []TimePeriod
+		expectedError bool
+	}{
+		{
+			name:        "default",
+			timePeriods: "-4h:0s:3s:2s,0s:1h:3s:2s,1h:8h:10s:2s,8h:32h:1m:5s,32h::5m:10s",
+			expected: []TimePeriod{
+				{Start: -4 * time.Hour, End: 0, RequestInterval: 3 * time.Second, RequestTimeout: 2 * time.Second},
+				{Start: 0, End: time.Hour, RequestInterval: 3 * time.Second, RequestTimeout: 2 * time.Second},
+				{Start: time.Hour, End: 8 * time.Hour, RequestInterval: 10 * time.Second, RequestTimeout: 2 * time.Second},
+				{Start: 8 * time.Hour, End: 32 * time.Hour, RequestInterval: time.Minute, RequestTimeout: 5 * time.Second},
+				{Start: 32 * time.Hour, Open: true, RequestInterval: 5 * time.Minute, RequestTimeout: 10 * time.Second},
+			},
+		},
+		{
+			name:        "single open",
+			timePeriods: "0s::1s:1s",
+			expected: []TimePeriod{
+				{Start: 0, Open: true, RequestInterval: time.Second, RequestTimeout: time.Second},
+			},
+		},
+		{
+			name:          "missing parts",
+			timePeriods:   "0s:1h:3s",
+			expectedError: true,
+		},
+		{
+			name:          "invalid start",
+			timePeriods:   "now:1h:3s:2s",
+			expectedError: true,
+		},
+		{
+			name:          "invalid end",
+			timePeriods:   "0s:later:3s:2s",
+			expectedError: true,
+		},
+		{
+			name:          "invalid request interval",
+			timePeriods:   "0s:1h:often:2s",
+			expectedError: true,
+		},
+		{
+			name:          "invalid request timeout",
+			timePeriods:   "0s:1h:3s:soon",
+			expectedError: true,
+		},
+		{
+			name:          "zero request interval",
+			timePeriods:   "0s:1h:0s:2s",
+			expectedError: true,
+		},
+		{
+			name:          "negative request timeout",
+			timePeriods:   "0s:1h:3s:-2s",
+			expectedError: true,
+		},
+		{
+			name:          "ends before it starts",
+			timePeriods:   "1h:0s:3s:2s",
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("PRE_MATCH_TIME_PERIODS", test.timePeriods)
+
+			cfg, err := NewConfig()
+			if test.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, cfg.PreMatch.TimePeriods)
+		})
+	}
+}
+
+func TestRole(t *testing.T) {
+	tests := []struct {
+		role          string
+		expectedError bool
+	}{
+		{role: PollerRole},
+		{role: APIRole},
+		{role: AllRole},
+		{role: "worker", expectedError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.role, func(t *testing.T) {
+			t.Setenv("ROLE", test.role)
+
+			cfg, err := NewConfig()
+			if test.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.role, cfg.App.Role)
+		})
+	}
+}
+
+func TestRemovedEnvs(t *testing.T) {
+	for _, k := range removedEnvs {
+		t.Run(k, func(t *testing.T) {
+			t.Setenv(k, "10s")
+
+			_, err := NewConfig()
+			require.ErrorContains(t, err, k)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package delta
+
+import (
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func NewEventAdded(event *pb.Event) *ladbrokesPb.Delta {
+	return &ladbrokesPb.Delta{
+		Type:            ladbrokesPb.Delta_EVENT_ADDED,
+		EventExternalId: event.ExternalId,
+		Event:           event,
+	}
+}
+
+func NewEventRemoved(id string) *ladbrokesPb.Delta {
+	return &ladbrokesPb.Delta{
+		Type:            ladbrokesPb.Delta_EVENT_REMOVED,
+		EventExternalId: id,
+	}
+}
+
+// GetEventsDeltas returns the deltas between the current events and the polled ones,
+// current events missing from the polled ones are treated as removed
+func GetEventsDeltas(current, polled []*pb.Event) []*ladbrokesPb.Delta {
+	var (
+		deltas = make([]*ladbrokesPb.Delta, 0)
+		curr   = make(map[string]*pb.Event, len(current))
+		pld    = make(map[string]struct{}, len(polled))
+	)
+	for _, e := range current {
+		curr[e.ExternalId] = e
+	}
+	for _, e := range polled {
+		pld[e.ExternalId] = struct{}{}
+
+		c, ok := curr[e.ExternalId]
+		if !ok {
+			deltas = append(deltas, NewEventAdded(e))
+			continue
+		}
+		deltas = append(deltas, GetEventDeltas(c, e)...)
+	}
+	for _, e := range current {
+		if _, ok := pld[e.ExternalId]; !ok {
+			deltas = append(deltas, NewEventRemoved(e.ExternalId))
+		}
+	}
+	return deltas
+}
+
+// GetEventDeltas returns the market and price deltas between two states of the same event
+func GetEventDeltas(current, updated *pb.Event) []*ladbrokesPb.Delta {
+	var (
+		deltas = make([]*ladbrokesPb.Delta, 0)
+		curr   = make(map[string]*pb.Market, len(current.Markets))
+		upd    = make(map[string]struct{}, len(updated.Markets))
+	)
+	for _, m := range current.Markets {
+		curr[m.ExternalId] = m
+	}
+	for _, m := range updated.Markets {
+		upd[m.ExternalId] = struct{}{}
+
+		c, ok := curr[m.ExternalId]
+		if !ok {
+			deltas = append(deltas, newMarketAdded(updated.ExternalId, m))
+			continue
+		}
+		deltas = append(deltas, getMarketDeltas(updated.ExternalId, c, m)...)
+	}
+	for _, m := range current.Markets {
+		if _, ok := upd[m.ExternalId]; !ok {
+			deltas = append(deltas, newMarketDelta(ladbrokesPb.Delta_MARKET_REMOVED, updated.ExternalId, m.ExternalId))
+		}
+	}
+	return deltas
+}
+
+func getMarketDeltas(eventId string, current, updated *pb.Market) []*ladbrokesPb.Delta {
+	if !haveSameOutcomes(current, updated) {
+		return []*ladbrokesPb.Delta{newMarketAdded(eventId, updated)}
+	}
+
+	var (
+		deltas = make([]*ladbrokesPb.Delta, 0)
+		curr   = make(map[string]*pb.Outcome, len(current.Outcomes))
+	)
+	for _, o := range current.Outcomes {
+		curr[o.ExternalId] = o
+	}
+	for _, o := range updated.Outcomes {
+		if !proto.Equal(curr[o.ExternalId], o) {
+			d := newMarketDelta(ladbrokesPb.Delta_PRICE_CHANGED, eventId, updated.ExternalId)
+			d.Outcome = o
+			deltas = append(deltas, d)
+		}
+	}
+
+	switch cs, us := isSuspended(current), isSuspended(updated); {
+	case !cs && us:
+		deltas = append(deltas, newMarketDelta(ladbrokesPb.Delta_MARKET_SUSPENDED, eventId, updated.ExternalId))
+	case cs && !us:
+		deltas = append(deltas, newMarketDelta(ladbrokesPb.Delta_MARKET_RESUMED, eventId, updated.ExternalId))
+	}
+	return deltas
+}
+
+func newMarketAdded(eventId string, market *pb.Market) *ladbrokesPb.Delta {
+	d := newMarketDelta(ladbrokesPb.Delta_MARKET_ADDED, eventId, market.ExternalId)
+	d.Market = market
+	return d
+}
+
+func newMarketDelta(deltaType ladbrokesPb.Delta_DeltaType, eventId, marketId string) *ladbrokesPb.Delta {
+	return &ladbrokesPb.Delta{
+		Type:             deltaType,
+		EventExternalId:  eventId,
+		MarketExternalId: &marketId,
+	}
+}
+
+func haveSameOutcomes(current, updated *pb.Market) bool {
+	if len(current.Outcomes) != len(updated.Outcomes) {
+		return false
+	}
+	ids := make(map[string]struct{}, len(current.Outcomes))
+	for _, o := range current.Outcomes {
+		ids[o.ExternalId] = struct{}{}
+	}
+	for _, o := range updated.Outcomes {
+		if _, ok := ids[o.ExternalId]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// a market is considered suspended if none of its outcomes is available
+func isSuspended(market *pb.Market) bool {
+	for _, o := range market.Outcomes {
+		if o.IsAvailable {
+			return false
+		}
+	}
+	return len(market.Outcomes) > 0
+}
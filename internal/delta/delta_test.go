@@ -0,0 +1,116 @@
+package delta_test
+
+import (
+	"testing"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/delta"
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetEventsDeltas(t *testing.T) {
+	tc := []struct {
+		name    string
+		current []*pb.Event
+		polled  []*pb.Event
+		types   []ladbrokesPb.Delta_DeltaType
+	}{
+		{
+			name:    "no changes",
+			current: []*pb.Event{newEvent("1", newMarket("10", newOutcome("100", "2", true)))},
+			polled:  []*pb.Event{newEvent("1", newMarket("10", newOutcome("100", "2", true)))},
+			types:   []ladbrokesPb.Delta_DeltaType{},
+		},
+		{
+			name:    "event added and removed",
+			current: []*pb.Event{newEvent("1")},
+			polled:  []*pb.Event{newEvent("2")},
+			types: []ladbrokesPb.Delta_DeltaType{
+				ladbrokesPb.Delta_EVENT_ADDED,
+				ladbrokesPb.Delta_EVENT_REMOVED,
+			},
+		},
+		{
+			name:    "market added and removed",
+			current: []*pb.Event{newEvent("1", newMarket("10"))},
+			polled:  []*pb.Event{newEvent("1", newMarket("11"))},
+			types: []ladbrokesPb.Delta_DeltaType{
+				ladbrokesPb.Delta_MARKET_ADDED,
+				ladbrokesPb.Delta_MARKET_REMOVED,
+			},
+		},
+		{
+			name:    "price changed",
+			current: []*pb.Event{newEvent("1", newMarket("10", newOutcome("100", "2", true), newOutcome("101", "3", true)))},
+			polled:  []*pb.Event{newEvent("1", newMarket("10", newOutcome("100", "2", true), newOutcome("101", "4", true)))},
+			types: []ladbrokesPb.Delta_DeltaType{
+				ladbrokesPb.Delta_PRICE_CHANGED,
+			},
+		},
+		{
+			name:    "market suspended",
+			current: []*pb.Event{newEvent("1", newMarket("10", newOutcome("100", "2", true)))},
+			polled:  []*pb.Event{newEvent("1", newMarket("10", newOutcome("100", "2", false)))},
+			types: []ladbrokesPb.Delta_DeltaType{
+				ladbrokesPb.Delta_PRICE_CHANGED,
+				ladbrokesPb.Delta_MARKET_SUSPENDED,
+			},
+		},
+		{
+			name:    "market resumed",
+			current: []*pb.Event{newEvent("1", newMarket("10", newOutcome("100", "2", false)))},
+			polled:  []*pb.Event{newEvent("1", newMarket("10", newOutcome("100", "2", true)))},
+			types: []ladbrokesPb.Delta_DeltaType{
+				ladbrokesPb.Delta_PRICE_CHANGED,
+				ladbrokesPb.Delta_MARKET_RESUMED,
+			},
+		},
+		{
+			name:    "market outcomes replaced",
+			current: []*pb.Event{newEvent("1", newMarket("10", newOutcome("100", "2", true)))},
+			polled:  []*pb.Event{newEvent("1", newMarket("10", newOutcome("101", "2", true)))},
+			types: []ladbrokesPb.Delta_DeltaType{
+				ladbrokesPb.Delta_MARKET_ADDED,
+			},
+		},
+	}
+	for _, c := range tc {
+		c := c
+
+		t.Run(c.name, func(t *testing.T) {
+			ds := delta.GetEventsDeltas(c.current, c.polled)
+
+			tps := make([]ladbrokesPb.Delta_DeltaType, 0, len(ds))
+			for _, d := range ds {
+				tps = append(tps, d.Type)
+			}
+			require.Equal(t, c.types, tps)
+		})
+	}
+}
+
+func newEvent(id string, markets ...*pb.Market) *pb.Event {
+	return &pb.Event{
+		ExternalId: id,
+		Markets:    markets,
+	}
+}
+
+func newMarket(id string, outcomes ...*pb.Outcome) *pb.Market {
+	return &pb.Market{
+		ExternalId: id,
+		Outcomes:   outcomes,
+	}
+}
+
+func newOutcome(id, numerator string, available bool) *pb.Outcome {
+	return &pb.Outcome{
+		ExternalId: id,
+		Odds: &pb.Odds{
+			Numerator:   numerator,
+			Denominator: "1",
+		},
+		IsAvailable: available,
+	}
+}
@@ -0,0 +1,96 @@
+package egress
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	sdkHttp "github.com/olafszymanski/int-sdk/http"
+)
+
+// Kind of the upstream response
+type Kind string
+
+const (
+	// genuine data, including the error statuses the callers handle themselves
+	KindData        Kind = "data"
+	KindBan         Kind = "ban"
+	KindMaintenance Kind = "maintenance"
+	KindGeoBlock    Kind = "geo_block"
+	// the body of the JSON response is cut off, usually by a proxy or a WAF dropping the connection
+	KindTruncated Kind = "truncated"
+)
+
+// the pages are recognized by their first bytes only
+const maxInspectedBodyLength = 8192
+
+var (
+	geoBlockMarkers = []string{
+		"not available in your country",
+		"not available in your region",
+		"not available in your location",
+		"restricted territory",
+		"geo-block",
+		"geoblock",
+	}
+	maintenanceMarkers = []string{
+		"maintenance",
+		"temporarily unavailable",
+		"be back soon",
+	}
+)
+
+// Classify tells the genuine data apart from the ban, maintenance and geo-block pages, and the truncated responses.
+// The bans come as 403 or 429, or as captcha and other HTML pages with any status
+func Classify(request *sdkHttp.Request, response *sdkHttp.Response) Kind {
+	switch response.Status {
+	case http.StatusUnavailableForLegalReasons:
+		return KindGeoBlock
+	case http.StatusServiceUnavailable:
+		return KindMaintenance
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		if containsAny(response.Body, geoBlockMarkers) {
+			return KindGeoBlock
+		}
+		return KindBan
+	}
+
+	if isHTML(response.Body) {
+		switch {
+		case containsAny(response.Body, geoBlockMarkers):
+			return KindGeoBlock
+		case containsAny(response.Body, maintenanceMarkers):
+			return KindMaintenance
+		default:
+			// none of the endpoints responds with HTML, it's a captcha or another challenge page
+			return KindBan
+		}
+	}
+	if response.Status == http.StatusOK && expectsJSON(request) && !json.Valid(response.Body) {
+		return KindTruncated
+	}
+	return KindData
+}
+
+func isHTML(body []byte) bool {
+	b := bytes.TrimSpace(body)
+	return len(b) > 0 && b[0] == '<'
+}
+
+func expectsJSON(request *sdkHttp.Request) bool {
+	return strings.Contains(request.URL, "responseFormat=json")
+}
+
+func containsAny(body []byte, markers []string) bool {
+	if len(body) > maxInspectedBodyLength {
+		body = body[:maxInspectedBodyLength]
+	}
+	b := strings.ToLower(string(body))
+	for _, m := range markers {
+		if strings.Contains(b, m) {
+			return true
+		}
+	}
+	return false
+}
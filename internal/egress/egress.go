@@ -0,0 +1,148 @@
+package egress
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/metrics"
+	"github.com/olafszymanski/int-sdk/http"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrBlocked = fmt.Errorf("blocked by upstream")
+
+// BlockedError is returned for the requests blocked by the host and for the ones not sent while the host cools down
+type BlockedError struct {
+	Host string
+	Kind Kind
+	// the requests to the host are sent again afterwards
+	Until time.Time
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("%s: %s %s until %s", ErrBlocked, e.Host, e.Kind, e.Until.Format(time.RFC3339))
+}
+
+func (e *BlockedError) Unwrap() error {
+	return ErrBlocked
+}
+
+// Switcher switches the egress the requests are sent through, e.g. to another IP address
+type Switcher interface {
+	Switch(host string, kind Kind) error
+}
+
+// Guard classifies the responses of the upstream hosts, once any of the hosts blocks a request, the requests to it
+// fail with the BlockedError right away until the cooldown passes, so that they don't extend the ban.
+// The egress is switched on every block if the switcher is set, off the request path
+type Guard struct {
+	doer     http.Doer
+	switcher Switcher
+	cooldown time.Duration
+	lock     sync.Mutex
+	blocks   map[string]*BlockedError
+	// the hosts the egress is being switched for
+	switching map[string]struct{}
+	now       func() time.Time
+}
+
+// NewGuard returns the guard of the doer, the switcher is optional
+func NewGuard(cfg *config.Config, doer http.Doer, switcher Switcher) *Guard {
+	return &Guard{
+		doer:      doer,
+		switcher:  switcher,
+		cooldown:  cfg.Egress.BlockCooldown,
+		blocks:    make(map[string]*BlockedError),
+		switching: make(map[string]struct{}),
+		now:       time.Now,
+	}
+}
+
+func (g *Guard) Do(request *http.Request) (*http.Response, error) {
+	host := getHost(request.URL)
+	if err := g.getBlock(host); err != nil {
+		return nil, err
+	}
+	res, err := g.doer.Do(request)
+	if err != nil {
+		return res, err
+	}
+	if k := Classify(request, res); k != KindData {
+		return nil, g.block(host, k)
+	}
+	return res, nil
+}
+
+// getBlock returns the block of the host if it's still cooling down
+func (g *Guard) getBlock(host string) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	b, ok := g.blocks[host]
+	if !ok {
+		return nil
+	}
+	if !g.now().Before(b.Until) {
+		delete(g.blocks, host)
+		return nil
+	}
+	return b
+}
+
+// block starts the cooldown of the host and switches the egress in the background, unless the host has been blocked
+// already by any of the requests sent before the cooldown started or its egress is still being switched
+func (g *Guard) block(host string, kind Kind) error {
+	g.lock.Lock()
+	if b, ok := g.blocks[host]; ok && g.now().Before(b.Until) {
+		g.lock.Unlock()
+		return b
+	}
+	b := &BlockedError{
+		Host:  host,
+		Kind:  kind,
+		Until: g.now().Add(g.cooldown),
+	}
+	g.blocks[host] = b
+	_, switching := g.switching[host]
+	sw := g.switcher != nil && !switching
+	if sw {
+		g.switching[host] = struct{}{}
+	}
+	g.lock.Unlock()
+
+	metrics.UpstreamBlocks.WithLabelValues(host, string(kind)).Inc()
+	logger := logrus.WithFields(logrus.Fields{
+		"host":  host,
+		"kind":  kind,
+		"until": b.Until,
+	})
+	logger.Error("blocked by upstream, cooling down")
+	if sw {
+		go g.switchEgress(logger, host, kind)
+	}
+	return b
+}
+
+// switchEgress switches the egress of the host, the requests are not held back by the switcher
+func (g *Guard) switchEgress(logger *logrus.Entry, host string, kind Kind) {
+	defer func() {
+		g.lock.Lock()
+		defer g.lock.Unlock()
+		delete(g.switching, host)
+	}()
+
+	if err := g.switcher.Switch(host, kind); err != nil {
+		logger.WithError(err).Warn("failed to switch egress")
+	}
+}
+
+func getHost(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
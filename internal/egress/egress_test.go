@@ -0,0 +1,206 @@
+package egress
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-sdk/http"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+const testJSONUrl = "https://a.com/Drilldown/Class/1?translationLang=en&responseFormat=json"
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		status   int
+		body     string
+		expected Kind
+	}{
+		{
+			name:     "data",
+			url:      testJSONUrl,
+			status:   200,
+			body:     `{"SSResponse":{"children":[]}}`,
+			expected: KindData,
+		},
+		{
+			name:     "not JSON endpoint",
+			url:      "https://push.a.com/push",
+			status:   200,
+			body:     "CL0000S0002sEVENT0",
+			expected: KindData,
+		},
+		{
+			name:     "handled status",
+			url:      testJSONUrl,
+			status:   408,
+			expected: KindData,
+		},
+		{
+			name:     "forbidden",
+			url:      testJSONUrl,
+			status:   403,
+			body:     "<html>Access Denied</html>",
+			expected: KindBan,
+		},
+		{
+			name:     "captcha",
+			url:      testJSONUrl,
+			status:   200,
+			body:     "\n<!DOCTYPE html><html><title>Verify you are human</title></html>",
+			expected: KindBan,
+		},
+		{
+			name:     "geo-block",
+			url:      testJSONUrl,
+			status:   403,
+			body:     "<html>Sorry, this site is not available in your country</html>",
+			expected: KindGeoBlock,
+		},
+		{
+			name:     "unavailable for legal reasons",
+			url:      testJSONUrl,
+			status:   451,
+			expected: KindGeoBlock,
+		},
+		{
+			name:     "maintenance page",
+			url:      testJSONUrl,
+			status:   200,
+			body:     "<html>We are down for Maintenance</html>",
+			expected: KindMaintenance,
+		},
+		{
+			name:     "service unavailable",
+			url:      testJSONUrl,
+			status:   503,
+			expected: KindMaintenance,
+		},
+		{
+			name:     "truncated",
+			url:      testJSONUrl,
+			status:   200,
+			body:     `{"SSResponse":{"children":[{"event":`,
+			expected: KindTruncated,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			k := Classify(&http.Request{URL: test.url}, &http.Response{Status: test.status, Body: []byte(test.body)})
+			require.Equal(t, test.expected, k)
+		})
+	}
+}
+
+type testDoer struct {
+	requests int
+	status   int
+}
+
+func (d *testDoer) Do(request *http.Request) (*http.Response, error) {
+	d.requests++
+	return &http.Response{Status: d.status, Body: []byte("{}")}, nil
+}
+
+type testSwitcher struct {
+	switches atomic.Int32
+	// the switches block until released, if set
+	release chan struct{}
+}
+
+func (s *testSwitcher) Switch(host string, kind Kind) error {
+	s.switches.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	return nil
+}
+
+func TestGuard(t *testing.T) {
+	var (
+		d   = &testDoer{status: 403}
+		sw  = &testSwitcher{}
+		cfg = &config.Config{}
+		now = time.Now()
+	)
+	cfg.Egress.BlockCooldown = time.Minute
+	g := NewGuard(cfg, d, sw)
+	g.now = func() time.Time { return now }
+
+	_, err := g.Do(&http.Request{URL: testJSONUrl})
+	var be *BlockedError
+	require.True(t, errors.As(err, &be))
+	require.ErrorIs(t, err, ErrBlocked)
+	require.Equal(t, KindBan, be.Kind)
+	require.Equal(t, "a.com", be.Host)
+	require.Eventually(t, func() bool {
+		return sw.switches.Load() == 1
+	}, time.Second, time.Millisecond)
+
+	// not sent while cooling down
+	d.status = 200
+	_, err = g.Do(&http.Request{URL: testJSONUrl})
+	require.ErrorIs(t, err, ErrBlocked)
+	require.Equal(t, 1, d.requests)
+
+	// other hosts are not affected
+	_, err = g.Do(&http.Request{URL: "https://b.com/Drilldown?responseFormat=json"})
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	res, err := g.Do(&http.Request{URL: testJSONUrl})
+	require.NoError(t, err)
+	require.Equal(t, 200, res.Status)
+	require.Equal(t, int32(1), sw.switches.Load())
+}
+
+func TestGuardSwitchAsync(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	var (
+		d   = &testDoer{status: 403}
+		sw  = &testSwitcher{release: make(chan struct{})}
+		cfg = &config.Config{}
+		now = time.Now()
+	)
+	cfg.Egress.BlockCooldown = time.Minute
+	g := NewGuard(cfg, d, sw)
+	g.now = func() time.Time { return now }
+
+	// the blocked request doesn't wait for the switch
+	_, err := g.Do(&http.Request{URL: testJSONUrl})
+	require.ErrorIs(t, err, ErrBlocked)
+	require.Eventually(t, func() bool {
+		return sw.switches.Load() == 1
+	}, time.Second, time.Millisecond)
+
+	// the host blocked again after the cooldown isn't switched while its switch is still in flight
+	now = now.Add(time.Minute)
+	_, err = g.Do(&http.Request{URL: testJSONUrl})
+	require.ErrorIs(t, err, ErrBlocked)
+	require.Equal(t, 2, d.requests)
+	close(sw.release)
+	require.Eventually(t, func() bool {
+		g.lock.Lock()
+		defer g.lock.Unlock()
+		return len(g.switching) == 0
+	}, time.Second, time.Millisecond)
+	require.Equal(t, int32(1), sw.switches.Load())
+
+	// once done, the next block switches it again
+	now = now.Add(time.Minute)
+	_, err = g.Do(&http.Request{URL: testJSONUrl})
+	require.ErrorIs(t, err, ErrBlocked)
+	require.Eventually(t, func() bool {
+		g.lock.Lock()
+		defer g.lock.Unlock()
+		return sw.switches.Load() == 2 && len(g.switching) == 0
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,50 @@
+package egress
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const webhookTimeout = 5 * time.Second
+
+// WebhookSwitcher switches the egress by notifying the webhook of the blocks, the switching itself is left to it
+type WebhookSwitcher struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookSwitcher(url string) *WebhookSwitcher {
+	return &WebhookSwitcher{
+		url: url,
+		client: &http.Client{
+			Timeout: webhookTimeout,
+		},
+	}
+}
+
+type webhookRequest struct {
+	Host string `json:"host"`
+	Kind Kind   `json:"kind"`
+}
+
+func (w *WebhookSwitcher) Switch(host string, kind Kind) error {
+	raw, err := json.Marshal(&webhookRequest{
+		Host: host,
+		Kind: kind,
+	})
+	if err != nil {
+		return err
+	}
+	res, err := w.client.Post(w.url, "application/json", bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected webhook status code: %v", res.StatusCode)
+	}
+	return nil
+}
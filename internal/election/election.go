@@ -0,0 +1,148 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// renews the lease only if it is still held by the instance
+	renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+	// releases the lease only if it is still held by the instance
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+)
+
+// Elector elects a single leader among the instances competing for the same lease, the lease is kept in Redis
+// and expires after the TTL unless renewed, so another instance takes over when the leader stops renewing it
+type Elector struct {
+	client *redis.Client
+	id     string
+	ttl    time.Duration
+}
+
+func NewElector(client *redis.Client, id string, ttl time.Duration) *Elector {
+	return &Elector{
+		client: client,
+		id:     id,
+		ttl:    ttl,
+	}
+}
+
+// Run calls run while holding the lease, the context passed to it is canceled once the lease is lost,
+// afterwards the instance competes for the lease again. It returns when the context is done or run fails
+func (e *Elector) Run(ctx context.Context, key string, run func(ctx context.Context) error) error {
+	logger := logrus.WithFields(logrus.Fields{
+		"lease":       key,
+		"instance_id": e.id,
+	})
+
+	for {
+		ok, err := e.client.SetNX(ctx, key, e.id, e.ttl).Result()
+		if err != nil && ctx.Err() == nil {
+			logger.WithError(err).Error("failed to acquire lease")
+		}
+		if ok {
+			logger.Info("lease acquired")
+			if err := e.lead(ctx, key, run); err != nil {
+				return err
+			}
+			logger.Warn("lease lost")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(e.getRenewInterval()):
+		}
+	}
+}
+
+// lead runs until the lease is lost or run returns, it returns nil if the lease was lost. The lease is given up once
+// it's held by another instance, or once it can't be renewed before it expires, the failed renewals are retried until then
+func (e *Elector) lead(ctx context.Context, key string, run func(ctx context.Context) error) error {
+	lctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		logger = logrus.WithField("lease", key)
+		// the lease expires the TTL after it was last renewed
+		renewed = time.Now()
+		errCh   = make(chan error, 1)
+	)
+	go func() {
+		errCh <- run(lctx)
+	}()
+
+	for {
+		select {
+		case err := <-errCh:
+			e.release(key)
+			if err != nil && lctx.Err() == nil {
+				return err
+			}
+			return ctx.Err()
+		case <-time.After(e.getRenewInterval()):
+			// once the context is done the lease is released as soon as run returns, renewing it would fail
+			if ctx.Err() != nil {
+				continue
+			}
+			ok, err := e.renew(ctx, key)
+			if err != nil {
+				// the next renewal is still in time
+				if time.Since(renewed)+e.getRenewInterval() < e.ttl {
+					logger.WithError(err).Warn("failed to renew lease, retrying")
+					continue
+				}
+				logger.WithError(err).Error("failed to renew lease in time")
+			}
+			if ok {
+				renewed = time.Now()
+				continue
+			}
+			cancel()
+			// the lease is given up before it expires unless it's held by another instance already, so run is likely
+			// to return before another instance takes over, it's not guaranteed though, e.g. if it doesn't return in time
+			if err := <-errCh; err != nil && !errors.Is(err, context.Canceled) {
+				logger.WithError(err).Error("run failed after losing lease")
+			}
+			return nil
+		}
+	}
+}
+
+// renew extends the lease, it returns false if the lease is not held by the instance anymore
+func (e *Elector) renew(ctx context.Context, key string) (bool, error) {
+	res, err := renewScript.Run(ctx, e.client, []string{key}, e.id, e.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (e *Elector) release(key string) {
+	// the context might be already done, the lease has to be released anyway
+	ctx, cancel := context.WithTimeout(context.Background(), e.ttl)
+	defer cancel()
+
+	if err := releaseScript.Run(ctx, e.client, []string{key}, e.id).Err(); err != nil {
+		logrus.WithError(err).WithField("lease", key).Error("failed to release lease")
+	}
+}
+
+func (e *Elector) getRenewInterval() time.Duration {
+	return e.ttl / 3
+}
@@ -0,0 +1,203 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testKey = "LEADER_BASKETBALL"
+	testTTL = 30 * time.Millisecond
+)
+
+func newTestClient(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		rc.Close()
+	})
+	return rc, mr
+}
+
+// failingHook fails the given number of the scripts run next
+type failingHook struct {
+	fails *atomic.Int32
+}
+
+func (h failingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h failingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if (cmd.Name() == "evalsha" || cmd.Name() == "eval") && h.fails.Add(-1) >= 0 {
+			err := errors.New("connection reset")
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h failingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// runElector runs the elector until the returned function is called, which returns the error Run returned,
+// leading is set while the instance holds the lease
+func runElector(e *Elector, leading *atomic.Bool) func() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- e.Run(ctx, testKey, func(ctx context.Context) error {
+			leading.Store(true)
+			defer leading.Store(false)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+	return func() error {
+		cancel()
+		return <-errCh
+	}
+}
+
+func TestElectorRenew(t *testing.T) {
+	var (
+		rc, mr  = newTestClient(t)
+		leading = &atomic.Bool{}
+		stop    = runElector(NewElector(rc, "a", testTTL), leading)
+	)
+	require.Eventually(t, leading.Load, time.Second, time.Millisecond)
+
+	// the lease is renewed before it expires
+	mr.FastForward(testTTL - 5*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return mr.TTL(testKey) > testTTL/2
+	}, time.Second, time.Millisecond)
+	v, err := mr.Get(testKey)
+	require.NoError(t, err)
+	require.Equal(t, "a", v)
+	require.True(t, leading.Load())
+
+	// the lease is released once the context is done
+	require.ErrorIs(t, stop(), context.Canceled)
+	require.False(t, mr.Exists(testKey))
+	require.False(t, leading.Load())
+}
+
+func TestElectorTakeover(t *testing.T) {
+	var (
+		rc, _ = newTestClient(t)
+		aLead = &atomic.Bool{}
+		bLead = &atomic.Bool{}
+		stopA = runElector(NewElector(rc, "a", testTTL), aLead)
+	)
+	require.Eventually(t, aLead.Load, time.Second, time.Millisecond)
+	stopB := runElector(NewElector(rc, "b", testTTL), bLead)
+	defer stopB()
+
+	// a single instance leads at a time
+	for i := 0; i < 10; i++ {
+		require.True(t, aLead.Load())
+		require.False(t, bLead.Load())
+		time.Sleep(testTTL / 3)
+	}
+
+	// the other instance takes over once the leader stops
+	require.ErrorIs(t, stopA(), context.Canceled)
+	require.Eventually(t, bLead.Load, time.Second, time.Millisecond)
+}
+
+func TestElectorLeaseExpired(t *testing.T) {
+	var (
+		rc, mr  = newTestClient(t)
+		leading = &atomic.Bool{}
+	)
+	// the lease of the leader which stopped renewing it
+	require.NoError(t, mr.Set(testKey, "a"))
+	mr.SetTTL(testKey, testTTL)
+
+	stop := runElector(NewElector(rc, "b", testTTL), leading)
+	defer stop()
+	time.Sleep(testTTL)
+	require.False(t, leading.Load())
+
+	mr.FastForward(testTTL)
+	require.Eventually(t, leading.Load, time.Second, time.Millisecond)
+}
+
+func TestElectorLeaseLost(t *testing.T) {
+	var (
+		rc, mr  = newTestClient(t)
+		leading = &atomic.Bool{}
+		stop    = runElector(NewElector(rc, "a", testTTL), leading)
+	)
+	defer stop()
+	require.Eventually(t, leading.Load, time.Second, time.Millisecond)
+
+	// the lease taken over by another instance stops the run
+	require.NoError(t, mr.Set(testKey, "b"))
+	require.Eventually(t, func() bool {
+		return !leading.Load()
+	}, time.Second, time.Millisecond)
+	v, err := mr.Get(testKey)
+	require.NoError(t, err)
+	require.Equal(t, "b", v)
+
+	// and the instance competes for the lease again
+	mr.Del(testKey)
+	require.Eventually(t, leading.Load, time.Second, time.Millisecond)
+}
+
+func TestElectorRenewFailed(t *testing.T) {
+	var (
+		rc, mr  = newTestClient(t)
+		fails   = &atomic.Int32{}
+		leading = &atomic.Bool{}
+	)
+	rc.AddHook(failingHook{fails: fails})
+	// the renewals are far enough apart for the delays of the scheduler not to matter
+	stop := runElector(NewElector(rc, "a", 10*testTTL), leading)
+	defer stop()
+	require.Eventually(t, leading.Load, time.Second, time.Millisecond)
+
+	// a single failed renewal is retried while the lease is still held
+	fails.Store(1)
+	require.Eventually(t, func() bool {
+		return fails.Load() < 0
+	}, time.Second, time.Millisecond)
+	require.True(t, leading.Load())
+	v, err := mr.Get(testKey)
+	require.NoError(t, err)
+	require.Equal(t, "a", v)
+
+	// the lease is given up once it can't be renewed in time
+	fails.Store(1000)
+	require.Eventually(t, func() bool {
+		return !leading.Load()
+	}, time.Second, time.Millisecond)
+}
+
+func TestElectorRunFailed(t *testing.T) {
+	var (
+		rc, mr  = newTestClient(t)
+		errRun  = errors.New("run failed")
+		elector = NewElector(rc, "a", testTTL)
+	)
+
+	err := elector.Run(context.Background(), testKey, func(ctx context.Context) error {
+		return errRun
+	})
+	require.ErrorIs(t, err, errRun)
+	require.False(t, mr.Exists(testKey))
+}
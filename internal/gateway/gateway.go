@@ -0,0 +1,271 @@
+package gateway
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/auth"
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	readHeaderTimeout = 5 * time.Second
+	shutdownTimeout   = 10 * time.Second
+)
+
+const (
+	liveRoute     = "GET /v1/{sport}/live"
+	preMatchRoute = "GET /v1/{sport}/prematch"
+	eventRoute    = "GET /v1/events/{id}"
+)
+
+// gateway exposes the gRPC services as a REST/JSON API, the requests are handled by the same implementations
+type gateway struct {
+	integration   pb.IntegrationServer
+	ladbrokes     ladbrokesPb.LadbrokesServer
+	authenticator *auth.Authenticator
+}
+
+// Start serves the gateway, over TLS if the config is not nil, until the context is done
+func Start(ctx context.Context, integration pb.IntegrationServer, ladbrokes ladbrokesPb.LadbrokesServer, authenticator *auth.Authenticator, tlsConfig *tls.Config, port string) error {
+	g := &gateway{
+		integration:   integration,
+		ladbrokes:     ladbrokes,
+		authenticator: authenticator,
+	}
+	s := &http.Server{
+		Addr:              fmt.Sprintf(":%s", port),
+		Handler:           g,
+		ReadHeaderTimeout: readHeaderTimeout,
+		TLSConfig:         tlsConfig,
+	}
+	go func() {
+		<-ctx.Done()
+		// the requests in flight are given the time to finish
+		sctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := s.Shutdown(sctx); err != nil {
+			s.Close()
+		}
+	}()
+
+	var err error
+	if tlsConfig != nil {
+		// the certificates are already loaded into the config
+		err = s.ListenAndServeTLS("", "")
+	} else {
+		err = s.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// ServeHTTP routes the requests:
+//
+//	GET /v1/{sport}/live
+//	GET /v1/{sport}/prematch
+//	GET /v1/events/{id}?sport={sport}
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, status.New(codes.Unimplemented, "method not allowed"))
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 3 || parts[0] != "v1" {
+		writeError(w, r, http.StatusNotFound, status.New(codes.NotFound, "route not found"))
+		return
+	}
+	var route string
+	switch {
+	case parts[1] == "events":
+		route = eventRoute
+	case parts[2] == "live":
+		route = liveRoute
+	case parts[2] == "prematch":
+		route = preMatchRoute
+	default:
+		writeError(w, r, http.StatusNotFound, status.New(codes.NotFound, "route not found"))
+		return
+	}
+
+	c, err := g.authenticator.Authenticate(r.Header.Get(auth.HeaderKey))
+	if err == nil {
+		var (
+			hs  = &headerStream{method: route}
+			ctx = grpc.NewContextWithServerTransportStream(r.Context(), hs)
+			msg proto.Message
+		)
+		switch route {
+		case eventRoute:
+			msg, err = g.getEvent(ctx, parts[2], r.URL.Query().Get("sport"))
+		case liveRoute:
+			msg, err = g.getEvents(ctx, parts[1], g.integration.GetLive)
+		case preMatchRoute:
+			msg, err = g.getEvents(ctx, parts[1], g.integration.GetPreMatch)
+		}
+		if err == nil {
+			g.authenticator.Observe(c, route, nil)
+			for k, vs := range hs.header {
+				for _, v := range vs {
+					w.Header().Add(k, v)
+				}
+			}
+			write(w, r, http.StatusOK, msg)
+			return
+		}
+	}
+	g.authenticator.Observe(c, route, err)
+
+	st := status.Convert(err)
+	writeError(w, r, getHttpStatus(st.Code()), st)
+}
+
+func (g *gateway) getEvents(ctx context.Context, sport string, get func(context.Context, *pb.Request) (*pb.Response, error)) (proto.Message, error) {
+	tp, err := parseSportType(sport)
+	if err != nil {
+		return nil, err
+	}
+	return get(ctx, &pb.Request{
+		SportType: tp,
+	})
+}
+
+func (g *gateway) getEvent(ctx context.Context, id, sport string) (proto.Message, error) {
+	var tp pb.SportType
+	if sport != "" {
+		t, err := parseSportType(sport)
+		if err != nil {
+			return nil, err
+		}
+		tp = t
+	}
+	return g.ladbrokes.GetEvent(ctx, &ladbrokesPb.EventRequest{
+		ExternalId: id,
+		SportType:  tp,
+	})
+}
+
+// headerStream captures the headers set by the gRPC implementations, so that they can be passed on as the HTTP ones
+type headerStream struct {
+	header metadata.MD
+	method string
+}
+
+func (s *headerStream) Method() string {
+	return s.method
+}
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *headerStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *headerStream) SetTrailer(_ metadata.MD) error {
+	return nil
+}
+
+func parseSportType(sport string) (pb.SportType, error) {
+	tp, ok := pb.SportType_value[strings.ToUpper(sport)]
+	if !ok {
+		return 0, status.Errorf(codes.InvalidArgument, "unknown sport type: %s", sport)
+	}
+	return pb.SportType(tp), nil
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, httpStatus int, st *status.Status) {
+	write(w, r, httpStatus, st.Proto())
+}
+
+// write encodes the message with protojson, it answers with 304 if the client already has the same body (ETag)
+// and compresses the body if the client accepts gzip
+func write(w http.ResponseWriter, r *http.Request, httpStatus int, msg proto.Message) {
+	body, err := protojson.Marshal(msg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	h.Set("Vary", "Accept-Encoding")
+
+	if httpStatus == http.StatusOK {
+		sum := sha256.Sum256(body)
+		etag := fmt.Sprintf("%q", hex.EncodeToString(sum[:16]))
+		h.Set("ETag", etag)
+		if matchesETag(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		if _, err := gw.Write(body); err == nil && gw.Close() == nil {
+			h.Set("Content-Encoding", "gzip")
+			body = buf.Bytes()
+		}
+	}
+
+	w.WriteHeader(httpStatus)
+	w.Write(body)
+}
+
+func matchesETag(ifNoneMatch, etag string) bool {
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == etag || t == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func getHttpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
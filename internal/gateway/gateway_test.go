@@ -0,0 +1,264 @@
+package gateway
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/auth"
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type testIntegration struct {
+	pb.UnimplementedIntegrationServer
+}
+
+func (testIntegration) GetLive(_ context.Context, _ *pb.Request) (*pb.Response, error) {
+	return &pb.Response{Events: []*pb.Event{{ExternalId: "1", IsLive: true}}}, nil
+}
+
+func (testIntegration) GetPreMatch(ctx context.Context, _ *pb.Request) (*pb.Response, error) {
+	grpc.SetHeader(ctx, metadata.Pairs("x-data-ready", "false"))
+	return &pb.Response{}, nil
+}
+
+type testLadbrokes struct {
+	ladbrokesPb.UnimplementedLadbrokesServer
+}
+
+func (testLadbrokes) GetEvent(_ context.Context, request *ladbrokesPb.EventRequest) (*pb.Event, error) {
+	if request.ExternalId != "1" {
+		return nil, status.Errorf(codes.NotFound, "event %s not found", request.ExternalId)
+	}
+	return &pb.Event{ExternalId: request.ExternalId}, nil
+}
+
+func newTestGateway(keys map[string]string) *gateway {
+	cfg := &config.Config{}
+	cfg.Auth.Keys = keys
+	cfg.Auth.RateLimit = 100
+	cfg.Auth.RateBurst = 100
+	return &gateway{
+		integration:   testIntegration{},
+		ladbrokes:     testLadbrokes{},
+		authenticator: auth.NewAuthenticator(cfg),
+	}
+}
+
+func TestGatewayRoutes(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		key            string
+		expectedStatus int
+		expectedBody   string
+		expectedHeader http.Header
+	}{
+		{
+			name:           "live",
+			method:         http.MethodGet,
+			path:           "/v1/basketball/live",
+			key:            "key1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"events":[{"externalId":"1", "isLive":true}]}`,
+		},
+		{
+			name:           "pre-match not ready",
+			method:         http.MethodGet,
+			path:           "/v1/BASKETBALL/prematch",
+			key:            "key1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{}`,
+			expectedHeader: http.Header{"X-Data-Ready": {"false"}},
+		},
+		{
+			name:           "event",
+			method:         http.MethodGet,
+			path:           "/v1/events/1?sport=basketball",
+			key:            "key1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"externalId":"1"}`,
+		},
+		{
+			name:           "event not found",
+			method:         http.MethodGet,
+			path:           "/v1/events/2",
+			key:            "key1",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"code":5, "message":"event 2 not found"}`,
+		},
+		{
+			name:           "unknown sport",
+			method:         http.MethodGet,
+			path:           "/v1/curling/live",
+			key:            "key1",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"code":3, "message":"unknown sport type: curling"}`,
+		},
+		{
+			name:           "unknown route",
+			method:         http.MethodGet,
+			path:           "/v1/basketball/results",
+			key:            "key1",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"code":5, "message":"route not found"}`,
+		},
+		{
+			name:           "unknown version",
+			method:         http.MethodGet,
+			path:           "/v2/basketball/live",
+			key:            "key1",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"code":5, "message":"route not found"}`,
+		},
+		{
+			name:           "method not allowed",
+			method:         http.MethodPost,
+			path:           "/v1/basketball/live",
+			key:            "key1",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedBody:   `{"code":12, "message":"method not allowed"}`,
+		},
+		{
+			name:           "unauthenticated",
+			method:         http.MethodGet,
+			path:           "/v1/basketball/live",
+			key:            "key2",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"code":16, "message":"missing or invalid api key"}`,
+		},
+	}
+
+	g := newTestGateway(map[string]string{"key1": "dashboard"})
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(test.method, test.path, nil)
+			r.Header.Set(auth.HeaderKey, test.key)
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, r)
+
+			require.Equal(t, test.expectedStatus, w.Code)
+			require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			require.JSONEq(t, test.expectedBody, w.Body.String())
+			for k := range test.expectedHeader {
+				require.Equal(t, test.expectedHeader.Values(k), w.Header().Values(k))
+			}
+		})
+	}
+}
+
+func TestGatewayETag(t *testing.T) {
+	var (
+		g = newTestGateway(nil)
+		r = httptest.NewRequest(http.MethodGet, "/v1/basketball/live", nil)
+		w = httptest.NewRecorder()
+	)
+	g.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	tests := []struct {
+		name           string
+		ifNoneMatch    string
+		expectedStatus int
+	}{
+		{
+			name:           "matching",
+			ifNoneMatch:    etag,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "weak matching",
+			ifNoneMatch:    `"other", W/` + etag,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "any",
+			ifNoneMatch:    "*",
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "not matching",
+			ifNoneMatch:    `"other"`,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/basketball/live", nil)
+			r.Header.Set("If-None-Match", test.ifNoneMatch)
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, r)
+
+			require.Equal(t, test.expectedStatus, w.Code)
+			require.Equal(t, etag, w.Header().Get("ETag"))
+			if test.expectedStatus == http.StatusNotModified {
+				require.Empty(t, w.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestGatewayGzip(t *testing.T) {
+	var (
+		g     = newTestGateway(nil)
+		plain = httptest.NewRecorder()
+		gz    = httptest.NewRecorder()
+	)
+	g.ServeHTTP(plain, httptest.NewRequest(http.MethodGet, "/v1/basketball/live", nil))
+	require.Empty(t, plain.Header().Get("Content-Encoding"))
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/basketball/live", nil)
+	r.Header.Set("Accept-Encoding", "br, gzip")
+	g.ServeHTTP(gz, r)
+	require.Equal(t, http.StatusOK, gz.Code)
+	require.Equal(t, "gzip", gz.Header().Get("Content-Encoding"))
+	require.Equal(t, "Accept-Encoding", gz.Header().Get("Vary"))
+	// the ETag is of the encoded message, regardless of the compression
+	require.Equal(t, plain.Header().Get("ETag"), gz.Header().Get("ETag"))
+
+	gr, err := gzip.NewReader(bytes.NewReader(gz.Body.Bytes()))
+	require.NoError(t, err)
+	body, err := io.ReadAll(gr)
+	require.NoError(t, err)
+	require.Equal(t, plain.Body.Bytes(), body)
+}
+
+func TestGetHttpStatus(t *testing.T) {
+	tests := []struct {
+		code     codes.Code
+		expected int
+	}{
+		{code: codes.OK, expected: http.StatusOK},
+		{code: codes.InvalidArgument, expected: http.StatusBadRequest},
+		{code: codes.NotFound, expected: http.StatusNotFound},
+		{code: codes.Unauthenticated, expected: http.StatusUnauthorized},
+		{code: codes.PermissionDenied, expected: http.StatusForbidden},
+		{code: codes.ResourceExhausted, expected: http.StatusTooManyRequests},
+		{code: codes.Unavailable, expected: http.StatusServiceUnavailable},
+		{code: codes.DeadlineExceeded, expected: http.StatusGatewayTimeout},
+		{code: codes.Unimplemented, expected: http.StatusNotImplemented},
+		{code: codes.Internal, expected: http.StatusInternalServerError},
+		{code: codes.Canceled, expected: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.code.String(), func(t *testing.T) {
+			require.Equal(t, test.expected, getHttpStatus(test.code))
+		})
+	}
+}
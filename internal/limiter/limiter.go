@@ -0,0 +1,251 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/metrics"
+	"github.com/olafszymanski/int-sdk/http"
+)
+
+var ErrThrottled = fmt.Errorf("request throttled")
+
+// Priority of the requests, the low priority ones yield to the high priority ones when the budget is tight
+type Priority int
+
+const (
+	PriorityHigh Priority = iota
+	PriorityLow
+)
+
+func (p Priority) String() string {
+	if p == PriorityLow {
+		return "low"
+	}
+	return "high"
+}
+
+// sessioner is implemented by the doers sending the requests of the same session through the same egress,
+// e.g. the proxy pool
+type sessioner interface {
+	WithSession(key string) http.Doer
+}
+
+// otherEndpoint labels the requests to the endpoints without their own budget
+const otherEndpoint = "other"
+
+// Limiter limits the rate of the requests sent upstream with the token buckets of their hosts and endpoints,
+// shared by every request sent through it. The requests wait for the tokens of all of their buckets up to the max wait,
+// the low priority ones leave the reserved part of the buckets to the high priority ones.
+// The waits are cut short once the context is done
+type Limiter struct {
+	ctx       context.Context
+	doer      http.Doer
+	lock      sync.Mutex
+	hosts     map[string]*bucket
+	endpoints map[string]*bucket
+	reserve   float64
+	maxWait   time.Duration
+	now       func() time.Time
+	sleep     func(ctx context.Context, duration time.Duration) error
+}
+
+func NewLimiter(ctx context.Context, cfg *config.Config, doer http.Doer) *Limiter {
+	l := &Limiter{
+		ctx:       ctx,
+		doer:      doer,
+		hosts:     make(map[string]*bucket, len(cfg.Limiter.Hosts)),
+		endpoints: make(map[string]*bucket, len(cfg.Limiter.Endpoints)),
+		reserve:   cfg.Limiter.LowPriorityReserve,
+		maxWait:   cfg.Limiter.MaxWait,
+		now:       time.Now,
+		sleep:     sleep,
+	}
+	for _, b := range cfg.Limiter.Hosts {
+		l.hosts[b.Name] = newBucket(b.Rate, b.Burst, l.now())
+	}
+	for _, b := range cfg.Limiter.Endpoints {
+		l.endpoints[b.Name] = newBucket(b.Rate, b.Burst, l.now())
+	}
+	return l
+}
+
+// Do sends the request with the high priority
+func (l *Limiter) Do(request *http.Request) (*http.Response, error) {
+	return l.do(request, PriorityHigh, l.doer)
+}
+
+// WithPriority returns the doer sending the requests through the limiter with the given priority
+func (l *Limiter) WithPriority(priority Priority) http.Doer {
+	return &priorityDoer{
+		limiter:  l,
+		priority: priority,
+		doer:     l.doer,
+	}
+}
+
+// WithSession returns the doer sending the requests of the session through the limiter with the high priority,
+// they are sent through the same egress if the underlying doer supports the sessions
+func (l *Limiter) WithSession(key string) http.Doer {
+	d := l.doer
+	if s, ok := d.(sessioner); ok {
+		d = s.WithSession(key)
+	}
+	return &priorityDoer{
+		limiter:  l,
+		priority: PriorityHigh,
+		doer:     d,
+	}
+}
+
+// do waits for the tokens of the request and sends it, it fails with ErrThrottled without sending the request
+// if the tokens are not available within the max wait, or with the error of the context if it's done while waiting
+func (l *Limiter) do(request *http.Request, priority Priority, doer http.Doer) (*http.Response, error) {
+	host, endpoint, buckets := l.getBuckets(request.URL)
+	if len(buckets) == 0 {
+		return doer.Do(request)
+	}
+
+	var (
+		deadline = l.now().Add(l.maxWait)
+		delayed  = false
+	)
+	for {
+		wait, ok := l.take(buckets, priority)
+		if ok {
+			break
+		}
+		if l.now().Add(wait).After(deadline) {
+			metrics.UpstreamRequestsThrottled.WithLabelValues(host, endpoint, priority.String(), "rejected").Inc()
+			return nil, fmt.Errorf("%w: %s %s", ErrThrottled, host, endpoint)
+		}
+		delayed = true
+		if err := l.sleep(l.ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+	if delayed {
+		metrics.UpstreamRequestsThrottled.WithLabelValues(host, endpoint, priority.String(), "delayed").Inc()
+	}
+	return doer.Do(request)
+}
+
+// take takes a token from every bucket if all of them have enough of them for the priority,
+// otherwise it takes none and returns how long to wait for them
+func (l *Limiter) take(buckets []*bucket, priority Priority) (time.Duration, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var (
+		now  = l.now()
+		wait time.Duration
+	)
+	for _, b := range buckets {
+		b.refill(now)
+		if w := b.wait(l.getRequiredTokens(b, priority)); w > wait {
+			wait = w
+		}
+	}
+	if wait > 0 {
+		return wait, false
+	}
+	for _, b := range buckets {
+		b.tokens--
+	}
+	return 0, true
+}
+
+// getRequiredTokens returns the tokens the bucket must have for the request of the priority to take one of them,
+// the low priority ones must leave the reserved part of the bucket behind
+func (l *Limiter) getRequiredTokens(b *bucket, priority Priority) float64 {
+	if priority == PriorityLow {
+		return 1 + l.reserve*(b.burst-1)
+	}
+	return 1
+}
+
+// getBuckets returns the host and the endpoint of the url together with their buckets,
+// the endpoint is the first path segment with a budget
+func (l *Limiter) getBuckets(rawUrl string) (string, string, []*bucket) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", otherEndpoint, nil
+	}
+	var (
+		host     = u.Hostname()
+		endpoint = otherEndpoint
+		buckets  = make([]*bucket, 0, 2)
+	)
+	if b, ok := l.hosts[host]; ok {
+		buckets = append(buckets, b)
+	}
+	for _, s := range strings.Split(u.Path, "/") {
+		if b, ok := l.endpoints[s]; ok {
+			endpoint = s
+			buckets = append(buckets, b)
+			break
+		}
+	}
+	return host, endpoint, buckets
+}
+
+// sleep blocks for the duration or until the context is done
+func sleep(ctx context.Context, duration time.Duration) error {
+	t := time.NewTimer(duration)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+type priorityDoer struct {
+	limiter  *Limiter
+	priority Priority
+	doer     http.Doer
+}
+
+func (d *priorityDoer) Do(request *http.Request) (*http.Response, error) {
+	return d.limiter.do(request, d.priority, d.doer)
+}
+
+// bucket is refilled with the rate of tokens per second up to the burst
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst int, now time.Time) *bucket {
+	return &bucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if el := now.Sub(b.last); el > 0 {
+		b.tokens = min(b.burst, b.tokens+el.Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// wait returns how long it takes for the bucket to have the tokens
+func (b *bucket) wait(tokens float64) time.Duration {
+	if b.tokens >= tokens {
+		return 0
+	}
+	return time.Duration(math.Ceil((tokens - b.tokens) / b.rate * float64(time.Second)))
+}
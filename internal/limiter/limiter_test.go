@@ -0,0 +1,146 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-sdk/http"
+	"github.com/stretchr/testify/require"
+)
+
+type testDoer struct {
+	requests int
+}
+
+func (d *testDoer) Do(request *http.Request) (*http.Response, error) {
+	d.requests++
+	return &http.Response{Status: 200}, nil
+}
+
+func newTestLimiter(t *testing.T, doer http.Doer) (*Limiter, *time.Time) {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Limiter.Hosts = []config.Budget{{Name: "a.com", Rate: 1, Burst: 4}}
+	cfg.Limiter.Endpoints = []config.Budget{{Name: "Events", Rate: 1, Burst: 2}}
+	cfg.Limiter.LowPriorityReserve = 0.5
+	cfg.Limiter.MaxWait = 2 * time.Second
+
+	now := time.Now()
+	l := NewLimiter(context.Background(), cfg, doer)
+	l.now = func() time.Time { return now }
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		now = now.Add(d)
+		return nil
+	}
+	for _, b := range l.hosts {
+		b.last = now
+	}
+	for _, b := range l.endpoints {
+		b.last = now
+	}
+	return l, &now
+}
+
+func TestLimiter(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		priority Priority
+		// requests sent before the first one has to wait
+		immediate int
+	}{
+		{
+			name:      "host budget",
+			url:       "https://a.com/Classes/1",
+			priority:  PriorityHigh,
+			immediate: 4,
+		},
+		{
+			name:      "host and endpoint budgets",
+			url:       "https://a.com/Events/1",
+			priority:  PriorityHigh,
+			immediate: 2,
+		},
+		{
+			name:     "low priority reserve",
+			url:      "https://a.com/Classes/1",
+			priority: PriorityLow,
+			// it leaves 1.5 tokens of the 4 behind
+			immediate: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				d      = &testDoer{}
+				l, now = newTestLimiter(t, d)
+				cl     = l.WithPriority(test.priority)
+				st     = *now
+			)
+			for i := 0; i < test.immediate; i++ {
+				_, err := cl.Do(&http.Request{URL: test.url})
+				require.NoError(t, err)
+			}
+			require.Equal(t, st, *now)
+
+			_, err := cl.Do(&http.Request{URL: test.url})
+			require.NoError(t, err)
+			require.True(t, now.After(st))
+			require.Equal(t, test.immediate+1, d.requests)
+		})
+	}
+}
+
+func TestLimiterThrottled(t *testing.T) {
+	var (
+		d    = &testDoer{}
+		l, _ = newTestLimiter(t, d)
+	)
+	// the request would have to wait longer than the max wait
+	l.maxWait = 0
+	for i := 0; i < 4; i++ {
+		_, err := l.Do(&http.Request{URL: "https://a.com/Classes/1"})
+		require.NoError(t, err)
+	}
+	_, err := l.Do(&http.Request{URL: "https://a.com/Classes/1"})
+	require.ErrorIs(t, err, ErrThrottled)
+	require.Equal(t, 4, d.requests)
+
+	// not limited hosts
+	_, err = l.Do(&http.Request{URL: "https://b.com/Classes/1"})
+	require.NoError(t, err)
+}
+
+func TestLimiterCanceled(t *testing.T) {
+	var (
+		d           = &testDoer{}
+		cfg         = &config.Config{}
+		ctx, cancel = context.WithCancel(context.Background())
+		waiting     = make(chan struct{})
+	)
+	cfg.Limiter.Hosts = []config.Budget{{Name: "a.com", Rate: 0.001, Burst: 1}}
+	cfg.Limiter.MaxWait = time.Hour
+	l := NewLimiter(ctx, cfg, d)
+	l.sleep = func(ctx context.Context, duration time.Duration) error {
+		close(waiting)
+		return sleep(ctx, duration)
+	}
+
+	_, err := l.Do(&http.Request{URL: "https://a.com/Classes/1"})
+	require.NoError(t, err)
+
+	// the request waiting for the tokens gives up once the context is done
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := l.Do(&http.Request{URL: "https://a.com/Classes/1"})
+		errCh <- err
+	}()
+	<-waiting
+	cancel()
+	require.ErrorIs(t, <-errCh, context.Canceled)
+	require.Equal(t, 1, d.requests)
+}
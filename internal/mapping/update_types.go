@@ -11,7 +11,7 @@ const (
 )
 
 var UpdateTypes = map[string]UpdateType{
-	"EVENT": MarketUpdateType,
+	"EVENT": EventUpdateType,
 	"EVMKT": MarketUpdateType,
 	"SELCN": SelectionUpdateType,
 	"PRICE": PriceUpdateType,
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "ladbrokes"
+
+var CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "client_cache",
+	Name:      "requests_total",
+	Help:      "Number of client cache lookups, partitioned by events hash and result (hit or miss).",
+}, []string{"hash", "result"})
+
+var APIRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "api",
+	Name:      "requests_total",
+	Help:      "Number of API requests, partitioned by consumer, method and status code (ResourceExhausted for throttled ones).",
+}, []string{"consumer", "method", "code"})
+
+var EventsOverlaps = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "poller",
+	Name:      "events_overlaps_total",
+	Help:      "Number of duplicated events returned by the overlapping requests of a single polling, partitioned by sport type.",
+}, []string{"sport_type"})
+
+var UpdatesQueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "poller",
+	Name:      "updates_queue_depth",
+	Help:      "Number of events with updates waiting to be applied, partitioned by sport type.",
+}, []string{"sport_type"})
+
+var EventsSuspensions = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "poller",
+	Name:      "events_suspensions_total",
+	Help:      "Number of live events suspended due to their degraded updates feed, partitioned by sport type.",
+}, []string{"sport_type"})
+
+var EventsRefreshes = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "poller",
+	Name:      "events_refreshes_total",
+	Help:      "Number of live events refreshed from their full snapshots, partitioned by sport type and trigger.",
+}, []string{"sport_type", "trigger"})
+
+var UpstreamRequestsThrottled = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "upstream",
+	Name:      "requests_throttled_total",
+	Help:      "Number of upstream requests delayed or rejected by the rate limiter, partitioned by host, endpoint, priority and result.",
+}, []string{"host", "endpoint", "priority", "result"})
+
+var UpstreamBlocks = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "upstream",
+	Name:      "blocks_total",
+	Help:      "Number of upstream responses classified as blocks, partitioned by host and kind.",
+}, []string{"host", "kind"})
+
+var ProxyHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "proxy",
+	Name:      "healthy",
+	Help:      "Whether the proxy is used for the requests, 0 while it's ejected, partitioned by proxy.",
+}, []string{"proxy"})
+
+var ProxyEjections = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "proxy",
+	Name:      "ejections_total",
+	Help:      "Number of proxy ejections, partitioned by proxy and reason.",
+}, []string{"proxy", "reason"})
+
+// Start serves the metrics in the Prometheus format on the given port
+func Start(port string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	s := &http.Server{
+		Addr:              fmt.Sprintf(":%s", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s.ListenAndServe()
+}
@@ -1,5 +1,7 @@
 package model
 
+import "encoding/json"
+
 type Price struct {
 	ID               string `json:"id"`
 	PriceType        string `json:"priceType"`
@@ -64,6 +66,9 @@ type Market struct {
 	} `json:"children"`
 }
 
+// RawIsOffYesCode marks the events which are off, even before they are flagged as started
+const RawIsOffYesCode = "Y"
+
 type Event struct {
 	ID                       string `json:"id"`
 	Name                     string `json:"name"`
@@ -104,8 +109,17 @@ type Event struct {
 
 type EventsRoot struct {
 	SSResponse struct {
-		Children []struct {
-			Event Event `json:"event"`
-		} `json:"children"`
+		Children []EventChild `json:"children"`
+	} `json:"SSResponse"`
+}
+
+type EventChild struct {
+	Event Event `json:"event"`
+}
+
+// RawEventsRoot is the events response with the children left undecoded
+type RawEventsRoot struct {
+	SSResponse struct {
+		Children []json.RawMessage `json:"children"`
 	} `json:"SSResponse"`
 }
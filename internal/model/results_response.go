@@ -0,0 +1,47 @@
+package model
+
+type ResultedOutcome struct {
+	ID         string `json:"id"`
+	MarketID   string `json:"marketId"`
+	Name       string `json:"name"`
+	ResultCode string `json:"resultCode"`
+	// result code of the football outcomes, e.g. H (home), D (draw), A (away)
+	FbResult   string `json:"fbResult"`
+	IsSettled  string `json:"isSettled"`
+	Position   string `json:"position"`
+	PriceNum   string `json:"priceNum"`
+	PriceDen   string `json:"priceDen"`
+	PriceDec   string `json:"priceDec"`
+	ResultedAt string `json:"resultedAt"`
+}
+
+type ResultedMarket struct {
+	ID             string `json:"id"`
+	EventID        string `json:"eventId"`
+	Name           string `json:"name"`
+	IsResulted     string `json:"isResulted"`
+	IsSettled      string `json:"isSettled"`
+	TemplateMarket string `json:"templateMarketName"`
+	Children       []struct {
+		ResultedOutcome ResultedOutcome `json:"resultedOutcome"`
+	} `json:"children"`
+}
+
+type ResultedEvent struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	IsResulted string `json:"isResulted"`
+	IsFinished string `json:"isFinished"`
+	StartTime  string `json:"startTime"`
+	Children   []struct {
+		ResultedMarket ResultedMarket `json:"resultedMarket"`
+	} `json:"children"`
+}
+
+type ResultsRoot struct {
+	SSResponse struct {
+		Children []struct {
+			ResultedEvent ResultedEvent `json:"resultedEvent"`
+		} `json:"children"`
+	} `json:"SSResponse"`
+}
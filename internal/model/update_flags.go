@@ -0,0 +1,3 @@
+package model
+
+const YesUpdateFlag = "Y"
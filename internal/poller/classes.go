@@ -20,38 +20,23 @@ const (
 )
 
 func (p *Poller) pollClasses(ctx context.Context, logger *logrus.Entry, sportType pb.SportType) error {
-	var (
-		startTime time.Time
-		classesCh = make(chan []byte)
-	)
-	defer close(classesCh)
-
-	for {
-		startTime = time.Now()
-		go func() {
-			cls, err := p.fetchClasses(sportType)
-			if err != nil {
-				logger.WithError(err).Error("polling classes failed")
-				return
-			}
-			if len(cls) == 0 {
-				logger.Warn("no classes polled")
-				return
-			}
-			classesCh <- cls
-		}()
+	return schedule(ctx, logger.WithField("polling", "classes"), p.config.Classes.RequestInterval, func(ctx context.Context) error {
+		cls, err := p.fetchClasses(sportType)
+		if err != nil {
+			logger.WithError(err).Error("polling classes failed")
+			return nil
+		}
+		if len(cls) == 0 {
+			logger.Warn("no classes polled")
+			return nil
+		}
 
-		select {
-		case cls := <-classesCh:
-			logger.WithField("classes_length", len(cls)).Debug("classes polled")
-			if err := p.storage.StoreClasses(ctx, fmt.Sprintf(classesStorageKey, sportType), cls); err != nil {
-				return fmt.Errorf("failed to store classes: %s", err)
-			}
-			<-time.After(p.config.Classes.RequestInterval - time.Since(startTime))
-		case <-time.After(p.config.Classes.RequestInterval):
-			logger.Warn("classes polling took longer than expected")
+		logger.WithField("classes_length", len(cls)).Debug("classes polled")
+		if err := p.storage.StoreClasses(ctx, fmt.Sprintf(classesStorageKey, sportType), cls); err != nil {
+			return fmt.Errorf("failed to store classes: %s", err)
 		}
-	}
+		return nil
+	})
 }
 
 func (p *Poller) fetchClasses(sportType pb.SportType) ([]byte, error) {
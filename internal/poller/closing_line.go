@@ -0,0 +1,58 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/olafszymanski/int-sdk/storage"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// captureStartedClosingLines stores the last pre-match state of the events whose start time has passed,
+// the rest of them might have been removed from the pre-match events for any other reason
+func (p *Poller) captureStartedClosingLines(ctx context.Context, sportType pb.SportType, ids []string) error {
+	hash := fmt.Sprintf(config.PreMatchEventsStorageKey, sportType)
+	for _, id := range ids {
+		if err := p.captureClosingLine(ctx, hash, id, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Poller) captureClosingLine(ctx context.Context, preMatchHash, id string, checkStartTime bool) error {
+	key := fmt.Sprintf(config.ClosingLineStorageKey, id)
+
+	_, err := p.storage.GetClosingLine(ctx, key)
+	if err == nil {
+		// the closing line is captured only once, the first time the event is seen as started
+		return nil
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+
+	ev, err := p.storage.GetEvent(ctx, preMatchHash, id)
+	if err != nil {
+		// the event has never been polled as a pre-match one, there is nothing to capture
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if checkStartTime && ev.StartTime.AsTime().After(time.Now()) {
+		return nil
+	}
+
+	// the closing line might have been captured since it was checked, the first capture is kept then
+	_, err = p.storage.StoreClosingLine(ctx, key, &ladbrokesPb.ClosingLine{
+		Event:      ev,
+		CapturedAt: timestamppb.Now(),
+	}, p.config.ClosingLine.Retention)
+	return err
+}
@@ -0,0 +1,122 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/olafszymanski/int-sdk/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCaptureClosingLine(t *testing.T) {
+	tests := []struct {
+		name           string
+		event          *pb.Event
+		checkStartTime bool
+		expected       bool
+	}{
+		{
+			name:           "started",
+			event:          &pb.Event{ExternalId: "1", StartTime: timestamppb.New(time.Now().Add(-time.Minute))},
+			checkStartTime: true,
+			expected:       true,
+		},
+		{
+			name:           "not started",
+			event:          &pb.Event{ExternalId: "1", StartTime: timestamppb.New(time.Now().Add(time.Minute))},
+			checkStartTime: true,
+			expected:       false,
+		},
+		{
+			name:           "not started, start time not checked",
+			event:          &pb.Event{ExternalId: "1", StartTime: timestamppb.New(time.Now().Add(time.Minute))},
+			checkStartTime: false,
+			expected:       true,
+		},
+		{
+			name:           "never polled",
+			checkStartTime: true,
+			expected:       false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				ctx  = context.Background()
+				p    = newTestRedisPoller(t, nil)
+				hash = fmt.Sprintf(config.PreMatchEventsStorageKey, pb.SportType_BASKETBALL)
+				key  = fmt.Sprintf(config.ClosingLineStorageKey, "1")
+			)
+			if test.event != nil {
+				require.NoError(t, p.storage.StoreEvent(ctx, hash, test.event))
+			}
+
+			require.NoError(t, p.captureClosingLine(ctx, hash, "1", test.checkStartTime))
+			cl, err := p.storage.GetClosingLine(ctx, key)
+			if !test.expected {
+				require.ErrorIs(t, err, storage.ErrNotFound)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.event.ExternalId, cl.Event.ExternalId)
+		})
+	}
+}
+
+func TestCaptureClosingLineOnce(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		p    = newTestRedisPoller(t, nil)
+		hash = fmt.Sprintf(config.PreMatchEventsStorageKey, pb.SportType_BASKETBALL)
+		key  = fmt.Sprintf(config.ClosingLineStorageKey, "1")
+	)
+	require.NoError(t, p.storage.StoreEvent(ctx, hash, &pb.Event{ExternalId: "1", Name: "first"}))
+	require.NoError(t, p.captureClosingLine(ctx, hash, "1", false))
+
+	// the later captures keep the first closing line
+	require.NoError(t, p.storage.StoreEvent(ctx, hash, &pb.Event{ExternalId: "1", Name: "changed"}))
+	require.NoError(t, p.captureClosingLine(ctx, hash, "1", false))
+
+	cl, err := p.storage.GetClosingLine(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, "first", cl.Event.Name)
+}
+
+func TestStoreClosingLineConcurrently(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		p      = newTestRedisPoller(t, nil)
+		key    = fmt.Sprintf(config.ClosingLineStorageKey, "1")
+		wg     sync.WaitGroup
+		lock   sync.Mutex
+		stored = 0
+		errs   error
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := p.storage.StoreClosingLine(ctx, key, &ladbrokesPb.ClosingLine{Event: &pb.Event{ExternalId: "1"}}, 0)
+
+			lock.Lock()
+			defer lock.Unlock()
+			errs = errors.Join(errs, err)
+			if ok {
+				stored++
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.NoError(t, errs)
+	require.Equal(t, 1, stored)
+}
@@ -1,6 +1,7 @@
 package poller
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -8,104 +9,244 @@ import (
 	"sync"
 	"time"
 
+	"github.com/olafszymanski/int-ladbrokes/internal/metrics"
+	"github.com/olafszymanski/int-ladbrokes/internal/shard"
 	"github.com/olafszymanski/int-ladbrokes/internal/transform"
 	sdkHttp "github.com/olafszymanski/int-sdk/http"
 	"github.com/olafszymanski/int-sdk/integration/pb"
 	"github.com/olafszymanski/int-sdk/storage"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
 	eventsUrl = "https://ss-aka-ori.ladbrokes.com/openbet-ssviewer/Drilldown/2.81/EventToOutcomeForClass/%s?simpleFilter=event.startTime:greaterThanOrEqual:%s&translationLang=en&responseFormat=json&prune=event&prune=market&childCount=event"
+	eventUrl  = "https://ss-aka-ori.ladbrokes.com/openbet-ssviewer/Drilldown/2.81/EventToOutcomeForEvent/%s?translationLang=en&responseFormat=json"
 
 	startTimelessThanFilter = "simpleFilter=event.startTime:lessThan:%s"
 )
 
-func (p *Poller) pollEvents(ctx context.Context, baseUrl string, sportType pb.SportType, timeout time.Duration, timePeriods []timePeriod) ([]*pb.Event, error) {
+// polledEvents are the events of the classes owned by the instance
+type polledEvents struct {
+	events []*pb.Event
+	// ids of the events classes mapped by the events ids
+	classes map[string]string
+	shard   *shard.Shard
+	// the classes owned by the instance, joined by commas
+	owned []byte
+}
+
+// owns returns the function telling whether the stored event with the given id is owned by the instance
+func (pe *polledEvents) owns(eventsClasses map[string]string) func(id string) bool {
+	return getEventsOwner(pe.shard, eventsClasses)
+}
+
+// pollEvents polls the events of the classes owned by the instance within the time periods relative to now,
+// it returns nil if the classes haven't been polled yet
+func (p *Poller) pollEvents(ctx context.Context, baseUrl string, sportType pb.SportType, timeout time.Duration, timePeriods []timePeriod, now time.Time) (*polledEvents, error) {
 	cls, err := p.storage.GetClasses(ctx, fmt.Sprintf(classesStorageKey, sportType))
 	if err != nil && !errors.Is(err, storage.ErrNotFound) {
 		return nil, err
 	}
+	// the classes haven't been polled yet, there is nothing to poll the events of
 	if len(cls) == 0 {
 		return nil, nil
 	}
-	return p.fetchEvents(baseUrl, cls, timeout, timePeriods)
+	sh, err := p.getShard(ctx, sportType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shard: %s", err)
+	}
+	pe := &polledEvents{
+		events:  make([]*pb.Event, 0),
+		classes: make(map[string]string),
+		shard:   sh,
+	}
+
+	pe.owned = getOwnedClasses(sh, cls)
+	if len(pe.owned) == 0 {
+		return pe, nil
+	}
+	evs, evsCls, err := p.fetchEvents(ctx, baseUrl, pe.owned, timeout, timePeriods, now)
+	if err != nil {
+		return nil, err
+	}
+	pe.classes = evsCls
+	pe.events = dedupeEvents(evs)
+	if o := len(evs) - len(pe.events); o > 0 {
+		metrics.EventsOverlaps.WithLabelValues(sportType.String()).Add(float64(o))
+	}
+	return pe, nil
 }
 
-func (p *Poller) fetchEvents(baseUrl string, classes []byte, timeout time.Duration, timePeriods []timePeriod) ([]*pb.Event, error) {
+// fetchEvents fetches the events of the time periods, the requests are split by the classes chunks
+// and by the parts of the time periods learned to keep the responses within the limits.
+// It returns once all of the requests have finished, the ones not sent yet are skipped after any of them failed
+func (p *Poller) fetchEvents(ctx context.Context, baseUrl string, classes []byte, timeout time.Duration, timePeriods []timePeriod, now time.Time) ([]*pb.Event, map[string]string, error) {
 	var (
-		requestsCount = len(timePeriods)
-		wg            = sync.WaitGroup{}
+		requests      = make([]*eventsRequest, 0)
 		lock          = sync.Mutex{}
 		events        = make([]*pb.Event, 0)
-		done          = make(chan struct{})
-		errCh         = make(chan error)
+		eventsClasses = make(map[string]string)
 	)
-	defer func() {
-		close(done)
-		close(errCh)
-	}()
-
-	wg.Add(requestsCount)
-	for i := 0; i < requestsCount; i++ {
-		i := i
-		go func() {
-			defer wg.Done()
-
-			var url string
-			if i == requestsCount-1 {
-				url = getLastUrl(
-					baseUrl,
-					classes,
-					&timePeriods[i],
-				)
-			} else {
-				url = getUrl(
-					baseUrl,
-					classes,
-					&timePeriods[i],
-				)
+	// the url without the classes is as long as the classes are allowed to be shorter than the max url length
+	baseLength := len(getUrl(baseUrl, nil, &timePeriod{}, now))
+	for _, tp := range timePeriods {
+		key := getPartitionKey(baseUrl, tp)
+		for _, part := range p.partitions.get(key, tp) {
+			for _, chunk := range chunkClasses(classes, p.config.Events.MaxUrlLength-baseLength) {
+				requests = append(requests, &eventsRequest{
+					key:        key,
+					baseUrl:    baseUrl,
+					classes:    chunk,
+					timePeriod: part,
+					now:        now,
+					timeout:    timeout,
+				})
 			}
+		}
+	}
 
-			evs, err := p.getEvents(url, timeout)
+	g, ctx := errgroup.WithContext(ctx)
+	for _, r := range requests {
+		r := r
+		g.Go(func() error {
+			evs, cls, err := p.fetchRequestEvents(ctx, r, 0)
 			if err != nil {
-				errCh <- err
-				return
+				return err
 			}
 			lock.Lock()
+			defer lock.Unlock()
 			events = append(events, evs...)
-			lock.Unlock()
-		}()
-	}
-	go func() {
-		wg.Wait()
-		done <- struct{}{}
-	}()
-
-	select {
-	case <-done:
-		return events, nil
-	case err := <-errCh:
-		return nil, err
+			for id, c := range cls {
+				eventsClasses[id] = c
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
 	}
+	return events, eventsClasses, nil
 }
 
-func (p *Poller) getEvents(url string, timeout time.Duration) ([]*pb.Event, error) {
-	res, err := p.httpClient.Do(&sdkHttp.Request{
+type eventsRequest struct {
+	// partition key of the time period the request is a part of
+	key        string
+	baseUrl    string
+	classes    []byte
+	timePeriod timePeriod
+	// the time the time period is relative to, the same for all of the requests of the polling
+	now     time.Time
+	timeout time.Duration
+}
+
+// fetchRequestEvents fetches the events of the request, a timed out request is split in halves and fetched again,
+// by its time period if possible, by its classes otherwise
+func (p *Poller) fetchRequestEvents(ctx context.Context, request *eventsRequest, depth int) ([]*pb.Event, map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var url string
+	if request.timePeriod.open {
+		url = getLastUrl(request.baseUrl, request.classes, &request.timePeriod, request.now)
+	} else {
+		url = getUrl(request.baseUrl, request.classes, &request.timePeriod, request.now)
+	}
+
+	evs, cls, err := p.getEvents(request.key, getResponseKey(request), &request.timePeriod, url, request.timeout)
+	if err == nil || !errors.Is(err, ErrRequestTimeout) || depth >= maxSplitDepth {
+		return evs, cls, err
+	}
+	p.partitions.markSplit(request.key, request.timePeriod)
+
+	a, b := *request, *request
+	if request.timePeriod.canSplit(p.config.Events.MinTimeWindow) {
+		a.timePeriod, b.timePeriod = request.timePeriod.split(p.config.Events.OpenTimeWindowSplit)
+	} else if a.classes, b.classes = splitClasses(request.classes); a.classes == nil {
+		return nil, nil, err
+	}
+
+	aEvs, aCls, err := p.fetchRequestEvents(ctx, &a, depth+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	bEvs, bCls, err := p.fetchRequestEvents(ctx, &b, depth+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	for id, c := range bCls {
+		aCls[id] = c
+	}
+	return append(aEvs, bEvs...), aCls, nil
+}
+
+func (p *Poller) getEvents(key, responseKey string, part *timePeriod, url string, timeout time.Duration) ([]*pb.Event, map[string]string, error) {
+	cl := p.httpClient
+	// the events starting far from now can wait, unlike the live ones
+	if part.start >= p.config.Limiter.LowPriorityAfter {
+		cl = p.lowPriorityClient
+	}
+	res, err := cl.Do(&sdkHttp.Request{
 		Method:  http.MethodGet,
 		URL:     url,
 		Timeout: timeout,
 	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.Status == http.StatusRequestTimeout {
+		return nil, nil, fmt.Errorf("%w: %s", ErrRequestTimeout, res.TimeTaken)
+	}
+	if res.Status != 200 {
+		return nil, nil, fmt.Errorf("%w: %v", ErrUnexpectedStatusCode, res.Status)
+	}
+	// too large or too slow responses are still used, the time period is split for the next polling
+	p.partitions.observe(key, *part, len(res.Body), res.TimeTaken, timeout)
+	return p.transforms.transformEvents(responseKey, res.Body)
+}
+
+// getResponseKey returns the key of the request which stays the same between the pollings, unlike its url
+func getResponseKey(request *eventsRequest) string {
+	return fmt.Sprintf("%s|%s", getPartitionKey(request.baseUrl, request.timePeriod), request.classes)
+}
+
+// fetchEvent fetches the current state of the single event with all of its markets, unlike the events snapshots
+// it's not pruned, it returns nil if the event is not available anymore. The markets of the unhandled types
+// dropped from it are remembered, so that their updates aren't mistaken for the ones of unknown markets
+func (p *Poller) fetchEvent(id string, timeout time.Duration) (*pb.Event, error) {
+	res, err := p.httpClient.Do(&sdkHttp.Request{
+		Method:  http.MethodGet,
+		URL:     fmt.Sprintf(eventUrl, id),
+		Timeout: timeout,
+	})
 	if err != nil {
 		return nil, err
 	}
 	if res.Status != 200 {
 		return nil, fmt.Errorf("%w: %v", ErrUnexpectedStatusCode, res.Status)
 	}
-	return transform.TransformEvents(res.Body)
+	evs, err := transform.TransformEvents(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	ums, err := transform.GetUnhandledMarkets(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if ids, ok := ums[id]; ok {
+		p.unhandled.set(id, ids)
+	}
+	for _, e := range evs {
+		if e.ExternalId == id {
+			return e, nil
+		}
+	}
+	return nil, nil
 }
 
-func getUrl(url string, classes []byte, timePeriod *timePeriod) string {
-	st, et := timePeriod.getTimes()
+func getUrl(url string, classes []byte, timePeriod *timePeriod, now time.Time) string {
+	st, et := timePeriod.getTimes(now)
 	return fmt.Sprintf(
 		fmt.Sprintf("%s&%s", url, startTimelessThanFilter),
 		classes,
@@ -114,7 +255,67 @@ func getUrl(url string, classes []byte, timePeriod *timePeriod) string {
 	)
 }
 
-func getLastUrl(url string, classes []byte, timePeriod *timePeriod) string {
-	st, _ := timePeriod.getTimes()
+func getLastUrl(url string, classes []byte, timePeriod *timePeriod, now time.Time) string {
+	st, _ := timePeriod.getTimes(now)
 	return fmt.Sprintf(url, classes, st.Format(time.RFC3339))
 }
+
+// dedupeEvents removes the events returned by more than one request, keeping the order of their first occurrences,
+// the copy with the latest start time wins, so that the choice does not depend on the order the responses came in
+func dedupeEvents(events []*pb.Event) []*pb.Event {
+	var (
+		evs = make([]*pb.Event, 0, len(events))
+		idx = make(map[string]int, len(events))
+	)
+	for _, e := range events {
+		i, ok := idx[e.ExternalId]
+		if !ok {
+			idx[e.ExternalId] = len(evs)
+			evs = append(evs, e)
+			continue
+		}
+		if isPreferredEvent(e, evs[i]) {
+			evs[i] = e
+		}
+	}
+	return evs
+}
+
+// isPreferredEvent checks whether the event should be kept over the other copy of it, the one with the latest
+// start time is preferred, the ties are broken by the number of markets and the encoded events as a last resort
+func isPreferredEvent(event, other *pb.Event) bool {
+	if st, ost := event.StartTime.AsTime(), other.StartTime.AsTime(); !st.Equal(ost) {
+		return st.After(ost)
+	}
+	if len(event.Markets) != len(other.Markets) {
+		return len(event.Markets) > len(other.Markets)
+	}
+	opts := proto.MarshalOptions{Deterministic: true}
+	raw, _ := opts.Marshal(event)
+	oraw, _ := opts.Marshal(other)
+	return bytes.Compare(raw, oraw) > 0
+}
+
+// getMissingEventsIds returns the ids of the current events which are not present in the polled ones
+func getMissingEventsIds(current, polled []*pb.Event) []string {
+	pld := make(map[string]struct{}, len(polled))
+	for _, e := range polled {
+		pld[e.ExternalId] = struct{}{}
+	}
+
+	ids := make([]string, 0)
+	for _, e := range current {
+		if _, ok := pld[e.ExternalId]; !ok {
+			ids = append(ids, e.ExternalId)
+		}
+	}
+	return ids
+}
+
+func getEventsIds(events []*pb.Event) []string {
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ExternalId)
+	}
+	return ids
+}
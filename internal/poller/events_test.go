@@ -0,0 +1,58 @@
+package poller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestDedupeEvents(t *testing.T) {
+	var (
+		startTime = timestamppb.New(time.Unix(1700000000, 0))
+		later     = timestamppb.New(time.Unix(1700003600, 0))
+	)
+
+	tests := []struct {
+		name      string
+		event     *pb.Event
+		other     *pb.Event
+		preferred *pb.Event
+	}{
+		{
+			name:      "later start time",
+			event:     &pb.Event{ExternalId: "1", StartTime: later},
+			other:     &pb.Event{ExternalId: "1", StartTime: startTime, Markets: []*pb.Market{{ExternalId: "10"}}},
+			preferred: &pb.Event{ExternalId: "1", StartTime: later},
+		},
+		{
+			name:      "more markets",
+			event:     &pb.Event{ExternalId: "1", StartTime: startTime, Markets: []*pb.Market{{ExternalId: "10"}, {ExternalId: "11"}}},
+			other:     &pb.Event{ExternalId: "1", StartTime: startTime, Markets: []*pb.Market{{ExternalId: "12"}}},
+			preferred: &pb.Event{ExternalId: "1", StartTime: startTime, Markets: []*pb.Market{{ExternalId: "10"}, {ExternalId: "11"}}},
+		},
+		{
+			name:      "encoded tiebreak",
+			event:     &pb.Event{ExternalId: "1", StartTime: startTime, Name: "b"},
+			other:     &pb.Event{ExternalId: "1", StartTime: startTime, Name: "a"},
+			preferred: &pb.Event{ExternalId: "1", StartTime: startTime, Name: "b"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the preferred copy is kept regardless of the order the responses came in
+			evs := dedupeEvents([]*pb.Event{test.event, {ExternalId: "2"}, test.other})
+			require.Len(t, evs, 2)
+			require.Equal(t, test.preferred.String(), evs[0].String())
+			require.Equal(t, "2", evs[1].ExternalId)
+
+			evs = dedupeEvents([]*pb.Event{test.other, {ExternalId: "2"}, test.event})
+			require.Len(t, evs, 2)
+			require.Equal(t, test.preferred.String(), evs[0].String())
+			require.Equal(t, "2", evs[1].ExternalId)
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package poller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"hash/maphash"
+	"sync"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/transform"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// transformCache keeps the last transformed events responses by their requests and the events transformed
+// from their raw JSON, so that the unchanged ones are neither decoded nor transformed again. The entries are looked up
+// by the hashes of their contents, which are compared in full on a hit, as the hashes might collide.
+// The cached events are shared by the pollings, they must not be modified. The entries not used within the TTL are dropped
+type transformCache struct {
+	lock      sync.Mutex
+	seed      maphash.Seed
+	responses map[string]*cachedResponse
+	events    map[uint64]*cachedEvent
+	ttl       time.Duration
+	lastPrune time.Time
+}
+
+type cachedResponse struct {
+	hash     uint64
+	raw      []byte
+	events   []*pb.Event
+	classes  map[string]string
+	lastUsed time.Time
+}
+
+type cachedEvent struct {
+	raw []byte
+	// nil if the raw event is not valid
+	event    *pb.Event
+	class    string
+	lastUsed time.Time
+}
+
+func newTransformCache(ttl time.Duration) *transformCache {
+	return &transformCache{
+		seed:      maphash.MakeSeed(),
+		responses: make(map[string]*cachedResponse),
+		events:    make(map[uint64]*cachedEvent),
+		ttl:       ttl,
+		lastPrune: time.Now(),
+	}
+}
+
+// transformEvents transforms the events response of the request together with the ids of the events classes
+// mapped by the events ids, the last result is returned if the response hasn't changed, otherwise only the changed
+// events are transformed
+func (c *transformCache) transformEvents(key string, rawData []byte) ([]*pb.Event, map[string]string, error) {
+	h := maphash.Bytes(c.seed, rawData)
+	if evs, cls, ok := c.getResponse(key, h, rawData); ok {
+		return evs, cls, nil
+	}
+
+	raw, err := transform.SplitEvents(rawData)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		evs = make([]*pb.Event, 0, len(raw))
+		cls = make(map[string]string, len(raw))
+	)
+	for _, r := range raw {
+		ev, cl, err := c.transformEvent(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ev == nil {
+			continue
+		}
+		evs = append(evs, ev)
+		cls[ev.ExternalId] = cl
+	}
+	c.putResponse(key, h, rawData, evs, cls)
+	return evs, cls, nil
+}
+
+// transformEvent transforms the raw event unless it has been transformed already
+func (c *transformCache) transformEvent(rawData []byte) (*pb.Event, string, error) {
+	h := maphash.Bytes(c.seed, rawData)
+
+	c.lock.Lock()
+	if ce, ok := c.events[h]; ok && bytes.Equal(ce.raw, rawData) {
+		ce.lastUsed = time.Now()
+		c.lock.Unlock()
+		return ce.event, ce.class, nil
+	}
+	c.lock.Unlock()
+
+	ev, cl, err := transform.TransformRawEvent(rawData)
+	if err != nil {
+		return nil, "", err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	// the colliding event is replaced, it's transformed again once it's back
+	c.events[h] = &cachedEvent{
+		raw:      rawData,
+		event:    ev,
+		class:    cl,
+		lastUsed: time.Now(),
+	}
+	return ev, cl, nil
+}
+
+func (c *transformCache) getResponse(key string, hash uint64, rawData []byte) ([]*pb.Event, map[string]string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	cr, ok := c.responses[key]
+	if !ok || cr.hash != hash || !bytes.Equal(cr.raw, rawData) {
+		return nil, nil, false
+	}
+	cr.lastUsed = time.Now()
+	return cr.events, cr.classes, true
+}
+
+func (c *transformCache) putResponse(key string, hash uint64, rawData []byte, events []*pb.Event, classes map[string]string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	c.responses[key] = &cachedResponse{
+		hash:     hash,
+		raw:      rawData,
+		events:   events,
+		classes:  classes,
+		lastUsed: now,
+	}
+	// the entries of the ended events and of the requests not sent anymore, e.g. after the time periods were split
+	if now.Sub(c.lastPrune) < c.ttl {
+		return
+	}
+	c.lastPrune = now
+	for k, cr := range c.responses {
+		if now.Sub(cr.lastUsed) > c.ttl {
+			delete(c.responses, k)
+		}
+	}
+	for h, ce := range c.events {
+		if now.Sub(ce.lastUsed) > c.ttl {
+			delete(c.events, h)
+		}
+	}
+}
+
+// eventsHashes are the content hashes of the last stored events mapped by their ids, the polled events are compared
+// against them, so that only the changed and the missing events have to be read from the storage
+type eventsHashes map[string][sha256.Size]byte
+
+func newEventsHashes(events []*pb.Event, classes map[string]string) (eventsHashes, error) {
+	h := make(eventsHashes, len(events))
+	if err := h.put(events, classes); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h eventsHashes) put(events []*pb.Event, classes map[string]string) error {
+	for _, e := range events {
+		sum, err := getEventHash(e, classes[e.ExternalId])
+		if err != nil {
+			return err
+		}
+		h[e.ExternalId] = sum
+	}
+	return nil
+}
+
+func (h eventsHashes) delete(ids []string) {
+	for _, id := range ids {
+		delete(h, id)
+	}
+}
+
+// getChanges returns the events which differ from the last stored ones or whose classes differ from the last stored ones,
+// together with the ids of the last stored events missing from them
+func (h eventsHashes) getChanges(events []*pb.Event, classes map[string]string) ([]*pb.Event, []string, error) {
+	var (
+		changed = make([]*pb.Event, 0)
+		missing = make([]string, 0)
+		polled  = make(map[string]struct{}, len(events))
+	)
+	for _, e := range events {
+		polled[e.ExternalId] = struct{}{}
+		sum, err := getEventHash(e, classes[e.ExternalId])
+		if err != nil {
+			return nil, nil, err
+		}
+		if s, ok := h[e.ExternalId]; !ok || s != sum {
+			changed = append(changed, e)
+		}
+	}
+	for id := range h {
+		if _, ok := polled[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	return changed, missing, nil
+}
+
+// getEventHash hashes the event together with its class, the hash is long enough for the collisions to never happen
+func getEventHash(event *pb.Event, class string) ([sha256.Size]byte, error) {
+	raw, err := proto.MarshalOptions{Deterministic: true}.Marshal(event)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	// the class is terminated, so that it can't be mistaken for a part of the event
+	return sha256.Sum256(append(append([]byte(class), 0), raw...)), nil
+}
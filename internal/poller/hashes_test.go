@@ -0,0 +1,159 @@
+package poller
+
+import (
+	"bytes"
+	"encoding/json"
+	"hash/maphash"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/transform"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformCache(t *testing.T) {
+	rawData, err := os.ReadFile("../transform/testdata/basketball/success.json")
+	require.NoError(t, err)
+	raw, err := transform.SplitEvents(rawData)
+	require.NoError(t, err)
+	require.Len(t, raw, 1)
+
+	var (
+		c      = newTransformCache(time.Minute)
+		first  = raw[0]
+		second = json.RawMessage(bytes.ReplaceAll(first, []byte("243810572"), []byte("243810573")))
+	)
+	evs, cls, err := c.transformEvents("key", getEventsResponse(t, first, second))
+	require.NoError(t, err)
+	expectedEvs, err := transform.TransformEvents(getEventsResponse(t, first, second))
+	require.NoError(t, err)
+	require.Equal(t, expectedEvs, evs)
+	require.Equal(t, map[string]string{"243810572": "25", "243810573": "25"}, cls)
+
+	// the unchanged response is not transformed again
+	cachedEvs, _, err := c.transformEvents("key", getEventsResponse(t, first, second))
+	require.NoError(t, err)
+	require.Same(t, evs[0], cachedEvs[0])
+	require.Same(t, evs[1], cachedEvs[1])
+
+	// only the changed events are transformed again
+	third := json.RawMessage(bytes.ReplaceAll(first, []byte("243810572"), []byte("243810574")))
+	changedEvs, changedCls, err := c.transformEvents("key", getEventsResponse(t, first, third))
+	require.NoError(t, err)
+	require.Len(t, changedEvs, 2)
+	require.Same(t, evs[0], changedEvs[0])
+	require.Equal(t, "243810574", changedEvs[1].ExternalId)
+	require.Equal(t, map[string]string{"243810572": "25", "243810574": "25"}, changedCls)
+
+	_, _, err = c.transformEvents("key", []byte("{"))
+	require.ErrorIs(t, err, transform.ErrDecodeResponse)
+}
+
+func TestTransformCacheCollision(t *testing.T) {
+	rawData, err := os.ReadFile("../transform/testdata/basketball/success.json")
+	require.NoError(t, err)
+	raw, err := transform.SplitEvents(rawData)
+	require.NoError(t, err)
+	require.Len(t, raw, 1)
+
+	var (
+		c        = newTransformCache(time.Minute)
+		response = getEventsResponse(t, raw[0])
+		other    = &pb.Event{ExternalId: "1"}
+	)
+	// the entries of the other contents under the same hashes
+	c.events[maphash.Bytes(c.seed, raw[0])] = &cachedEvent{raw: []byte("{}"), event: other, lastUsed: time.Now()}
+	c.responses["key"] = &cachedResponse{hash: maphash.Bytes(c.seed, response), raw: []byte("{}"), events: []*pb.Event{other}, lastUsed: time.Now()}
+
+	evs, cls, err := c.transformEvents("key", response)
+	require.NoError(t, err)
+	require.Len(t, evs, 1)
+	require.Equal(t, "243810572", evs[0].ExternalId)
+	require.Equal(t, map[string]string{"243810572": "25"}, cls)
+}
+
+func TestTransformCachePrune(t *testing.T) {
+	c := newTransformCache(time.Minute)
+	c.putResponse("old", 1, nil, nil, nil)
+	c.events[1] = &cachedEvent{lastUsed: time.Now().Add(-2 * time.Minute)}
+	c.responses["old"].lastUsed = time.Now().Add(-2 * time.Minute)
+
+	// pruned at most once per TTL
+	c.putResponse("new", 2, nil, nil, nil)
+	require.Contains(t, c.responses, "old")
+
+	c.lastPrune = time.Now().Add(-2 * time.Minute)
+	c.putResponse("new", 2, nil, nil, nil)
+	require.NotContains(t, c.responses, "old")
+	require.Contains(t, c.responses, "new")
+	require.Empty(t, c.events)
+}
+
+func TestEventsHashesGetChanges(t *testing.T) {
+	var (
+		event        = &pb.Event{ExternalId: "1", Markets: []*pb.Market{{ExternalId: "10"}}}
+		eventCopy    = &pb.Event{ExternalId: "1", Markets: []*pb.Market{{ExternalId: "10"}}}
+		eventChanged = &pb.Event{ExternalId: "1", Markets: []*pb.Market{{ExternalId: "11"}}}
+		newEvent     = &pb.Event{ExternalId: "2"}
+	)
+
+	tests := []struct {
+		name            string
+		stored          []*pb.Event
+		events          []*pb.Event
+		storedClasses   map[string]string
+		classes         map[string]string
+		expectedChanged []*pb.Event
+		expectedMissing []string
+	}{
+		{
+			name:            "unchanged",
+			stored:          []*pb.Event{event},
+			events:          []*pb.Event{eventCopy},
+			storedClasses:   map[string]string{"1": "25"},
+			classes:         map[string]string{"1": "25"},
+			expectedChanged: []*pb.Event{},
+			expectedMissing: []string{},
+		},
+		{
+			name:            "changed and new",
+			stored:          []*pb.Event{event},
+			events:          []*pb.Event{eventChanged, newEvent},
+			storedClasses:   map[string]string{"1": "25"},
+			classes:         map[string]string{"1": "25", "2": "25"},
+			expectedChanged: []*pb.Event{eventChanged, newEvent},
+			expectedMissing: []string{},
+		},
+		{
+			name:            "changed class",
+			stored:          []*pb.Event{event},
+			events:          []*pb.Event{eventCopy},
+			storedClasses:   map[string]string{"1": "25"},
+			classes:         map[string]string{"1": "26"},
+			expectedChanged: []*pb.Event{eventCopy},
+			expectedMissing: []string{},
+		},
+		{
+			name:            "missing",
+			stored:          []*pb.Event{event},
+			events:          []*pb.Event{newEvent},
+			storedClasses:   map[string]string{"1": "25"},
+			classes:         map[string]string{"2": "25"},
+			expectedChanged: []*pb.Event{newEvent},
+			expectedMissing: []string{"1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, err := newEventsHashes(test.stored, test.storedClasses)
+			require.NoError(t, err)
+			changed, missing, err := h.getChanges(test.events, test.classes)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedChanged, changed)
+			require.Equal(t, test.expectedMissing, missing)
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/delta"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/olafszymanski/int-sdk/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+// feedHealth tracks the health of the updates subscriptions, the events whose subscriptions have been failing
+// or silent for too long are suspended, so that their stale odds are never seen as available
+type feedHealth struct {
+	lock       sync.Mutex
+	events     map[string]*eventHealth
+	staleAfter time.Duration
+	maxErrors  int
+}
+
+type eventHealth struct {
+	lastSuccess time.Time
+	// the time the subscription started waiting for a long poll slot, zero while it's not waiting
+	queuedAt time.Time
+	// consecutive failed long polls
+	errors int
+	// the stored event might be suspended, it's restored once the subscription succeeds again
+	suspended bool
+	// the stored event has been checked since the tracking started, as it might have been suspended
+	// before the instance started
+	checked bool
+}
+
+func newFeedHealth(staleAfter time.Duration, maxErrors int) *feedHealth {
+	return &feedHealth{
+		events:     make(map[string]*eventHealth),
+		staleAfter: staleAfter,
+		maxErrors:  maxErrors,
+	}
+}
+
+// track starts tracking the subscription of the event, the event is suspended if the subscription doesn't succeed
+// within the stale threshold, while its first success checks whether it was suspended before the instance started
+func (f *feedHealth) track(id string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.events[id] = &eventHealth{
+		lastSuccess: time.Now(),
+	}
+}
+
+func (f *feedHealth) untrack(id string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.events, id)
+}
+
+// queue records the subscription of the event waiting for a long poll slot, the time it waits doesn't count
+// towards the stale threshold, as it can't receive anything before its long poll is sent
+func (f *feedHealth) queue(id string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if h, ok := f.events[id]; ok {
+		h.queuedAt = time.Now()
+	}
+}
+
+// dequeue records the subscription of the event having acquired a long poll slot
+func (f *feedHealth) dequeue(id string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	h, ok := f.events[id]
+	if !ok || h.queuedAt.IsZero() {
+		return
+	}
+	h.lastSuccess = h.lastSuccess.Add(time.Since(h.queuedAt))
+	h.queuedAt = time.Time{}
+}
+
+// succeed records the successful long poll of the event, it returns true if the event has to be restored
+func (f *feedHealth) succeed(id string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	h, ok := f.events[id]
+	if !ok {
+		return false
+	}
+	h.lastSuccess = time.Now()
+	h.errors = 0
+	return h.suspended || !h.checked
+}
+
+// fail records the failed long poll of the event
+func (f *feedHealth) fail(id string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if h, ok := f.events[id]; ok {
+		h.errors++
+	}
+}
+
+func (f *feedHealth) restored(id string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if h, ok := f.events[id]; ok {
+		h.suspended = false
+		h.checked = true
+	}
+}
+
+func (f *feedHealth) isSuspended(id string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	h, ok := f.events[id]
+	return ok && h.suspended
+}
+
+// suspendUnhealthy marks the events without a successful long poll within the stale threshold, not counting the time
+// spent waiting for a long poll slot, or with too many consecutive failed ones suspended,
+// it returns the ids of the events which weren't suspended already
+func (f *feedHealth) suspendUnhealthy(now time.Time) []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	ids := make([]string, 0)
+	for id, h := range f.events {
+		if h.suspended {
+			continue
+		}
+		end := now
+		if !h.queuedAt.IsZero() {
+			end = h.queuedAt
+		}
+		if end.Sub(h.lastSuccess) > f.staleAfter || h.errors >= f.maxErrors {
+			h.suspended = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// monitorFeedHealth suspends the events of the unhealthy subscriptions on the check interval until the context is done
+func (p *Poller) monitorFeedHealth(ctx context.Context, logger *logrus.Entry, hash string, health *feedHealth, suspensions prometheus.Counter) error {
+	return schedule(ctx, logger.WithField("polling", "feed health"), p.config.Updates.HealthCheckInterval, func(ctx context.Context) error {
+		for _, id := range health.suspendUnhealthy(time.Now()) {
+			logger.WithField("event_external_id", id).Warn("updates feed degraded, suspending event")
+			if err := p.suspendEvent(ctx, hash, id, health); err != nil {
+				return fmt.Errorf("failed to suspend event: %s", err)
+			}
+			suspensions.Inc()
+		}
+		return nil
+	})
+}
+
+// suspendEvent marks the outcomes of the stored event unavailable, unless the event has been restored in the meantime
+func (p *Poller) suspendEvent(ctx context.Context, hash, id string, health *feedHealth) error {
+	unlock := p.locks.acquire(id)
+	defer unlock()
+
+	if !health.isSuspended(id) {
+		return nil
+	}
+	ev, err := p.storage.GetEvent(ctx, hash, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get event from storage: %s", err)
+	}
+	curr, _ := proto.Clone(ev).(*pb.Event)
+	if !suspendOutcomes(ev) {
+		return nil
+	}
+	if err := p.storage.StoreEvent(ctx, hash, ev); err != nil {
+		return fmt.Errorf("failed to save event: %s", err)
+	}
+	if err := p.storage.PublishDeltas(ctx, fmt.Sprintf(config.EventsDeltasChannelKey, hash), delta.GetEventDeltas(curr, ev)); err != nil {
+		return fmt.Errorf("failed to publish event deltas: %s", err)
+	}
+	return nil
+}
+
+// restoreEvent resyncs the suspended event from its snapshot, the updates don't carry the availability of the outcomes
+func (p *Poller) restoreEvent(ctx context.Context, hash, id string, health *feedHealth) error {
+	unlock := p.locks.acquire(id)
+	defer unlock()
+
+	ev, err := p.storage.GetEvent(ctx, hash, id)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("failed to get event from storage: %s", err)
+	}
+	if ev != nil && isEventSuspended(ev) {
+		if err := p.resyncEvent(ctx, hash, id); err != nil {
+			return err
+		}
+	}
+	health.restored(id)
+	return nil
+}
+
+// suspendOutcomes marks every outcome of the event unavailable, it returns false if all of them were already
+func suspendOutcomes(event *pb.Event) bool {
+	changed := false
+	for _, m := range event.Markets {
+		for _, o := range m.Outcomes {
+			if o.IsAvailable {
+				o.IsAvailable = false
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// isEventSuspended checks whether the event looks suspended, with none of its outcomes available
+func isEventSuspended(event *pb.Event) bool {
+	outcomes := 0
+	for _, m := range event.Markets {
+		for _, o := range m.Outcomes {
+			if o.IsAvailable {
+				return false
+			}
+			outcomes++
+		}
+	}
+	return outcomes > 0
+}
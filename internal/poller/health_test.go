@@ -0,0 +1,103 @@
+package poller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedHealth(t *testing.T) {
+	var (
+		f   = newFeedHealth(time.Minute, 2)
+		now = time.Now()
+	)
+	f.track("1")
+	f.track("2")
+	f.track("3")
+	require.False(t, f.isSuspended("1"))
+	// the events might have been suspended before, they are checked on their first success
+	require.True(t, f.succeed("1"))
+	f.restored("1")
+	require.True(t, f.succeed("2"))
+	f.restored("2")
+	require.True(t, f.succeed("3"))
+	f.restored("3")
+	require.Empty(t, f.suspendUnhealthy(now))
+
+	f.fail("1")
+	require.Empty(t, f.suspendUnhealthy(now))
+	f.fail("1")
+	require.Equal(t, []string{"1"}, f.suspendUnhealthy(now))
+	// suspended events are not returned again
+	require.Empty(t, f.suspendUnhealthy(now))
+
+	// silent for longer than the stale threshold
+	f.events["2"].lastSuccess = now.Add(-2 * time.Minute)
+	require.Equal(t, []string{"2"}, f.suspendUnhealthy(now))
+
+	require.True(t, f.succeed("1"))
+	f.restored("1")
+	require.False(t, f.succeed("1"))
+
+	f.untrack("2")
+	require.False(t, f.isSuspended("2"))
+}
+
+func TestFeedHealthNeverSucceeded(t *testing.T) {
+	var (
+		f   = newFeedHealth(time.Minute, 2)
+		now = time.Now()
+	)
+	f.track("1")
+	f.track("2")
+	require.Empty(t, f.suspendUnhealthy(now))
+
+	// the subscriptions fail or stay silent from the start
+	f.fail("1")
+	f.fail("1")
+	f.events["2"].lastSuccess = now.Add(-2 * time.Minute)
+	require.ElementsMatch(t, []string{"1", "2"}, f.suspendUnhealthy(now))
+	require.True(t, f.isSuspended("1"))
+	require.True(t, f.isSuspended("2"))
+}
+
+func TestFeedHealthQueued(t *testing.T) {
+	f := newFeedHealth(time.Minute, 2)
+	f.track("1")
+	f.track("2")
+	f.events["1"].lastSuccess = time.Now().Add(-30 * time.Second)
+	f.events["2"].lastSuccess = time.Now().Add(-30 * time.Second)
+
+	// the subscriptions waiting for a long poll slot are not stale, however long they wait
+	f.queue("1")
+	f.queue("2")
+	require.Empty(t, f.suspendUnhealthy(time.Now().Add(time.Hour)))
+
+	// once the slot is acquired, the stale threshold counts from where it stopped before waiting
+	f.events["2"].queuedAt = time.Now().Add(-time.Hour)
+	f.dequeue("2")
+	require.Empty(t, f.suspendUnhealthy(time.Now().Add(time.Hour)))
+	require.Equal(t, []string{"2"}, f.suspendUnhealthy(time.Now().Add(time.Hour+time.Minute)))
+
+	// failing ones are suspended even while waiting
+	f.fail("1")
+	f.fail("1")
+	require.Equal(t, []string{"1"}, f.suspendUnhealthy(time.Now()))
+}
+
+func TestSuspendOutcomes(t *testing.T) {
+	ev := &pb.Event{
+		Markets: []*pb.Market{
+			{Outcomes: []*pb.Outcome{{IsAvailable: true}, {IsAvailable: false}}},
+			{Outcomes: []*pb.Outcome{{IsAvailable: true}}},
+		},
+	}
+	require.False(t, isEventSuspended(ev))
+	require.True(t, suspendOutcomes(ev))
+	require.True(t, isEventSuspended(ev))
+	require.False(t, suspendOutcomes(ev))
+
+	require.False(t, isEventSuspended(&pb.Event{}))
+}
@@ -0,0 +1,212 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/delta"
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/olafszymanski/int-sdk/storage"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// startEvents moves the started events from the pre-match to the live ones as soon as any of the snapshots or the pushes
+// tells they have started, each of them is moved atomically, so that it's in exactly one of them at any time.
+// Their closing lines are captured from the pre-match events before moving them
+func (p *Poller) startEvents(ctx context.Context, sportType pb.SportType, events []*pb.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	var (
+		preMatchHash   = fmt.Sprintf(config.PreMatchEventsStorageKey, sportType)
+		liveHash       = fmt.Sprintf(config.LiveEventsStorageKey, sportType)
+		preMatchDeltas = make([]*ladbrokesPb.Delta, 0)
+		liveDeltas     = make([]*ladbrokesPb.Delta, 0)
+	)
+	for _, e := range events {
+		if err := p.captureClosingLine(ctx, preMatchHash, e.ExternalId, false); err != nil {
+			return fmt.Errorf("failed to capture closing line: %s", err)
+		}
+		removed, added, err := p.storage.MoveEvent(ctx, preMatchHash, liveHash, e)
+		if err != nil {
+			return fmt.Errorf("failed to move event: %s", err)
+		}
+		if removed {
+			preMatchDeltas = append(preMatchDeltas, delta.NewEventRemoved(e.ExternalId))
+		}
+		// the events being live already have been stored by the updates, their deltas are published by them
+		if added {
+			liveDeltas = append(liveDeltas, delta.NewEventAdded(e))
+		}
+	}
+
+	if err := p.storage.PublishDeltas(ctx, fmt.Sprintf(config.EventsDeltasChannelKey, preMatchHash), preMatchDeltas); err != nil {
+		return fmt.Errorf("failed to publish pre-match events deltas: %s", err)
+	}
+	if err := p.storage.PublishDeltas(ctx, fmt.Sprintf(config.EventsDeltasChannelKey, liveHash), liveDeltas); err != nil {
+		return fmt.Errorf("failed to publish live events deltas: %s", err)
+	}
+	return nil
+}
+
+// splitStartedEvents splits the events into the started and the not started ones
+func splitStartedEvents(events []*pb.Event) ([]*pb.Event, []*pb.Event) {
+	var (
+		started    = make([]*pb.Event, 0)
+		notStarted = make([]*pb.Event, 0, len(events))
+	)
+	for _, e := range events {
+		if e.IsLive {
+			started = append(started, e)
+		} else {
+			notStarted = append(notStarted, e)
+		}
+	}
+	return started, notStarted
+}
+
+// watchStartingEvents subscribes to the pushed updates of the owned pre-match events starting within the start watch,
+// the updates subscriptions of the live events don't cover them yet. The events are moved to the live ones as soon as
+// the push tells they have started, instead of waiting for the next polling to notice it
+func (p *Poller) watchStartingEvents(ctx context.Context, logger *logrus.Entry, sportType pb.SportType) error {
+	if p.config.Lifecycle.StartWatch <= 0 {
+		return nil
+	}
+	var (
+		hash      = fmt.Sprintf(config.PreMatchEventsStorageKey, sportType)
+		watched   = make(map[string]context.CancelFunc)
+		longPolls = make(chan struct{}, p.config.Updates.MaxLongPolls)
+	)
+	logger = logger.WithField("polling", "starting events")
+	// every event is long polled in its own goroutine, the group makes sure none of them outlives the watch
+	g, ctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		return schedule(ctx, logger, p.config.Lifecycle.WatchInterval, func(ctx context.Context) error {
+			evs, err := p.storage.GetEvents(ctx, hash)
+			if err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return fmt.Errorf("failed to get pre-match events: %s", err)
+			}
+			cls, err := p.storage.GetEventsClasses(ctx, hash)
+			if err != nil {
+				return fmt.Errorf("failed to get pre-match events classes: %s", err)
+			}
+			sh, err := p.getShard(ctx, sportType)
+			if err != nil {
+				return fmt.Errorf("failed to get shard: %s", err)
+			}
+			ids := getStartingEventsIds(filterOwnedEvents(evs, getEventsOwner(sh, cls)), time.Now(), p.config.Lifecycle.StartWatch, p.config.Lifecycle.StartGrace)
+
+			// the events moved to the live ones in the meantime are watched by their updates subscriptions
+			for id, cancel := range watched {
+				if _, ok := ids[id]; !ok {
+					cancel()
+					delete(watched, id)
+				}
+			}
+			for id := range ids {
+				if _, ok := watched[id]; ok {
+					continue
+				}
+				wctx, cancel := context.WithCancel(ctx)
+				watched[id] = cancel
+
+				id := id
+				g.Go(func() error {
+					p.watchEventStart(wctx, logger, sportType, id, longPolls)
+					return nil
+				})
+			}
+			return nil
+		})
+	})
+	return g.Wait()
+}
+
+// watchEventStart long polls the updates of the pre-match event until the context is done or the event has started,
+// the started event is moved to the live ones right away. The failures are retried, as the pollings move the event anyway
+func (p *Poller) watchEventStart(ctx context.Context, logger *logrus.Entry, sportType pb.SportType, id string, longPolls chan struct{}) {
+	var (
+		body = []byte(fmt.Sprintf(defaultRequestBody, id))
+		cl   = p.getSessionClient(id)
+	)
+	logger = logger.WithField("event_external_id", id)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case longPolls <- struct{}{}:
+		}
+		update, err := p.getUpdates(cl, body, time.Second*60)
+		<-longPolls
+
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			logger.WithError(err).Warn("failed to receive update")
+			if err := wait(ctx, p.config.Live.RequestInterval); err != nil {
+				return
+			}
+			continue
+		}
+		if update == nil {
+			if err := wait(ctx, p.config.Live.RequestInterval); err != nil {
+				return
+			}
+			continue
+		}
+
+		body = getRequestBody(id, update.RequestBodyParts)
+		started, err := isEventStarted(update)
+		if err != nil {
+			logger.WithError(err).Warn("failed to check event status")
+			continue
+		}
+		if !started {
+			continue
+		}
+		if err := p.startPreMatchEvent(ctx, sportType, id); err != nil {
+			logger.WithError(err).Warn("failed to start event")
+			continue
+		}
+		logger.Debug("event started on push")
+		return
+	}
+}
+
+// startPreMatchEvent moves the stored pre-match event to the live ones, with the lock of the event held
+func (p *Poller) startPreMatchEvent(ctx context.Context, sportType pb.SportType, id string) error {
+	unlock := p.locks.acquire(id)
+	defer unlock()
+
+	ev, err := p.storage.GetEvent(ctx, fmt.Sprintf(config.PreMatchEventsStorageKey, sportType), id)
+	if err != nil {
+		// the event has been moved in the meantime
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get event from storage: %s", err)
+	}
+	ev.IsLive = true
+	return p.startEvents(ctx, sportType, []*pb.Event{ev})
+}
+
+// getStartingEventsIds returns the ids of the events starting within the watch from now,
+// or started within the grace period and not moved to the live ones yet
+func getStartingEventsIds(events []*pb.Event, now time.Time, watch, grace time.Duration) map[string]struct{} {
+	ids := make(map[string]struct{})
+	for _, e := range events {
+		st := e.StartTime.AsTime()
+		if st.After(now.Add(watch)) || now.Sub(st) >= grace {
+			continue
+		}
+		ids[e.ExternalId] = struct{}{}
+	}
+	return ids
+}
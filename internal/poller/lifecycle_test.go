@@ -0,0 +1,81 @@
+package poller
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkHttp "github.com/olafszymanski/int-sdk/http"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/olafszymanski/int-sdk/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSplitPendingStartEvents(t *testing.T) {
+	var (
+		now    = time.Now()
+		events = []*pb.Event{
+			{ExternalId: "just-started", StartTime: timestamppb.New(now.Add(-10 * time.Second))},
+			{ExternalId: "started-long-ago", StartTime: timestamppb.New(now.Add(-2 * time.Minute))},
+			{ExternalId: "not-started", StartTime: timestamppb.New(now.Add(time.Hour))},
+			{ExternalId: "polled", StartTime: timestamppb.New(now.Add(-10 * time.Second))},
+		}
+	)
+	pending, miss := splitPendingStartEvents(events, []string{"just-started", "started-long-ago", "not-started", "unknown"}, time.Minute)
+	require.Equal(t, []string{"just-started"}, getEventsIds(pending))
+	require.Equal(t, []string{"started-long-ago", "not-started", "unknown"}, miss)
+}
+
+func TestSplitStartedEvents(t *testing.T) {
+	started, notStarted := splitStartedEvents([]*pb.Event{
+		{ExternalId: "1", IsLive: true},
+		{ExternalId: "2"},
+	})
+	require.Equal(t, []string{"1"}, getEventsIds(started))
+	require.Equal(t, []string{"2"}, getEventsIds(notStarted))
+}
+
+func TestGetStartingEventsIds(t *testing.T) {
+	var (
+		now    = time.Now()
+		events = []*pb.Event{
+			{ExternalId: "starting", StartTime: timestamppb.New(now.Add(2 * time.Minute))},
+			{ExternalId: "not-starting", StartTime: timestamppb.New(now.Add(time.Hour))},
+			{ExternalId: "just-started", StartTime: timestamppb.New(now.Add(-10 * time.Second))},
+			{ExternalId: "started-long-ago", StartTime: timestamppb.New(now.Add(-2 * time.Minute))},
+		}
+	)
+	ids := getStartingEventsIds(events, now, 5*time.Minute, time.Minute)
+	require.Equal(t, map[string]struct{}{"starting": {}, "just-started": {}}, ids)
+}
+
+func TestWatchEventStart(t *testing.T) {
+	const id = "243810572"
+
+	var (
+		ctx     = context.Background()
+		started atomic.Bool
+		p       = newTestRedisPoller(t, doerFunc(func(request *sdkHttp.Request) (*sdkHttp.Response, error) {
+			// the event starts on the second long poll
+			if !started.Swap(true) {
+				return &sdkHttp.Response{Status: 200, Body: []byte("MSEVENT0" + id + `!!!!'o0:FCGsEVENT0` + id + `00001e00001e{"started":"N"}`)}, nil
+			}
+			return &sdkHttp.Response{Status: 200, Body: []byte("MSEVENT0" + id + `!!!!'o0:FDGsEVENT0` + id + `00001e00001e{"started":"Y"}`)}, nil
+		}))
+		preMatchHash = "PRE_MATCH_EVENTS_BASKETBALL"
+		liveHash     = "LIVE_EVENTS_BASKETBALL"
+	)
+	require.NoError(t, p.storage.StoreEvents(ctx, preMatchHash, []*pb.Event{{ExternalId: id, StartTime: timestamppb.Now()}}))
+
+	// returns once the event has started
+	p.watchEventStart(ctx, logrus.NewEntry(logrus.New()), pb.SportType_BASKETBALL, id, make(chan struct{}, 1))
+
+	_, err := p.storage.GetEvent(ctx, preMatchHash, id)
+	require.ErrorIs(t, err, storage.ErrNotFound)
+	ev, err := p.storage.GetEvent(ctx, liveHash, id)
+	require.NoError(t, err)
+	require.True(t, ev.IsLive)
+}
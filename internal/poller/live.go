@@ -6,64 +6,126 @@ import (
 	"time"
 
 	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/delta"
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
 	"github.com/olafszymanski/int-sdk/integration/pb"
 	"github.com/sirupsen/logrus"
 )
 
 const liveFilter = "simpleFilter=event.isStarted:isTrue"
 
-func (p *Poller) pollLiveEvents(ctx context.Context, logger *logrus.Entry, sportType pb.SportType) error {
+// liveSetChange is a change of the set of the live events polled by the instance
+type liveSetChange struct {
+	added   []string
+	removed []string
+}
+
+func (p *Poller) pollLiveEvents(ctx context.Context, logger *logrus.Entry, sportType pb.SportType, changes chan<- *liveSetChange) error {
 	var (
-		startTime   time.Time
 		timePeriods = []timePeriod{
-			{-4 * time.Hour, 0}, // last (and first in this case) element does not have an end time
+			{start: -4 * time.Hour, open: true},
 		}
-		eventsCh   = make(chan []*pb.Event)
-		noEventsCh = make(chan struct{})
-		errCh      = make(chan error)
+		// ids of the live events polled by the last polling
+		polled = make(map[string]struct{})
 	)
-	defer func() {
-		close(eventsCh)
-		close(noEventsCh)
-		close(errCh)
-	}()
+	// sends the changes of the polled live events set to the updates
+	notify := func(ctx context.Context, events []*pb.Event) error {
+		c := getLiveSetChange(polled, events)
+		if len(c.added) == 0 && len(c.removed) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case changes <- c:
+		}
+		polled = make(map[string]struct{}, len(events))
+		for _, e := range events {
+			polled[e.ExternalId] = struct{}{}
+		}
+		return nil
+	}
 
-	for {
-		startTime = time.Now()
+	return schedule(ctx, logger.WithField("polling", "live"), p.config.Live.RequestInterval, func(ctx context.Context) error {
+		hash := fmt.Sprintf(config.LiveEventsStorageKey, sportType)
 
-		go func() {
-			u := fmt.Sprintf("%s&%s", eventsUrl, liveFilter)
-			evs, err := p.pollEvents(ctx, u, sportType, p.config.Live.RequestTimeout, timePeriods)
-			if err != nil {
-				errCh <- fmt.Errorf("polling live events failed: %w", err)
-				return
-			}
-			if len(evs) == 0 {
-				noEventsCh <- struct{}{}
-				return
-			}
-			eventsCh <- evs
-		}()
-
-		select {
-		// if no events were polled, we want to retry after the request interval
-		case <-noEventsCh:
-			<-time.After(p.config.Live.RequestInterval - time.Since(startTime))
-		case evs := <-eventsCh:
-			logger.WithField("length", len(evs)).Debug("live events polled")
+		u := fmt.Sprintf("%s&%s", eventsUrl, liveFilter)
+		pe, err := p.pollEvents(ctx, u, sportType, p.config.Live.RequestTimeout, timePeriods, time.Now())
+		if err != nil {
+			return fmt.Errorf("polling live events failed: %w", err)
+		}
+		// if the classes haven't been polled yet, we want to retry after the request interval
+		// nothing has been polled, the missing events can't be told apart from the ones not polled yet
+		if pe == nil {
+			return nil
+		}
+		evs := pe.events
+		logger.WithField("length", len(evs)).Debug("live events polled")
 
-			hash := fmt.Sprintf(config.LiveEventsStorageKey, sportType)
-			if err := p.storage.RemoveMissingEvents(ctx, hash, evs); err != nil {
+		newEvs, err := p.storage.GetNewEvents(ctx, hash, evs)
+		if err != nil {
+			return fmt.Errorf("failed to get new live events: %s", err)
+		}
+		cls, err := p.storage.GetEventsClasses(ctx, hash)
+		if err != nil {
+			return fmt.Errorf("failed to get live events classes: %s", err)
+		}
+		miss, err := p.storage.GetMissingEventsIds(ctx, hash, evs, pe.owns(cls))
+		if err != nil {
+			return fmt.Errorf("failed to get missing live events: %s", err)
+		}
+		if len(miss) > 0 {
+			if err := p.storage.DeleteEvents(ctx, hash, miss); err != nil {
 				return fmt.Errorf("failed to remove missing live events: %s", err)
 			}
-			if err := p.storage.StoreNewEvents(ctx, hash, evs); err != nil {
-				return fmt.Errorf("failed to store live events: %s", err)
+			// the ended events might not be fully resulted yet, their results are queried until they are
+			if err := p.storage.AddPendingResults(ctx, fmt.Sprintf(config.PendingResultsStorageKey, sportType), miss); err != nil {
+				return fmt.Errorf("failed to add pending results: %s", err)
 			}
-			<-time.After(p.config.Live.RequestInterval - time.Since(startTime))
-		case <-time.After(p.config.Live.RequestInterval):
-			logger.Warn("live events polling took longer than expected")
-		case err := <-errCh:
-			return err
+		}
+		// the new live events are moved from the pre-match ones, unless they haven't been polled as such
+		if err := p.startEvents(ctx, sportType, newEvs); err != nil {
+			return fmt.Errorf("failed to start live events: %s", err)
+		}
+		if err := p.storage.StoreEventsClasses(ctx, hash, pe.classes); err != nil {
+			return fmt.Errorf("failed to store live events classes: %s", err)
+		}
+		// already stored live events are kept up to date by the updates, and the new ones are published once started,
+		// only the removed ones are published here
+		if err := p.storage.PublishDeltas(ctx, fmt.Sprintf(config.EventsDeltasChannelKey, hash), getRemovedDeltas(miss)); err != nil {
+			return fmt.Errorf("failed to publish live events deltas: %s", err)
+		}
+		if err := p.storage.MarkEventsSynced(ctx, hash); err != nil {
+			return fmt.Errorf("failed to mark live events synced: %s", err)
+		}
+		// the change is sent after storing the events, so that the updates always find them
+		return notify(ctx, evs)
+	})
+}
+
+func getRemovedDeltas(missingIds []string) []*ladbrokesPb.Delta {
+	deltas := make([]*ladbrokesPb.Delta, 0, len(missingIds))
+	for _, id := range missingIds {
+		deltas = append(deltas, delta.NewEventRemoved(id))
+	}
+	return deltas
+}
+
+func getLiveSetChange(previous map[string]struct{}, events []*pb.Event) *liveSetChange {
+	var (
+		c    = &liveSetChange{}
+		curr = make(map[string]struct{}, len(events))
+	)
+	for _, e := range events {
+		curr[e.ExternalId] = struct{}{}
+		if _, ok := previous[e.ExternalId]; !ok {
+			c.added = append(c.added, e.ExternalId)
+		}
+	}
+	for id := range previous {
+		if _, ok := curr[id]; !ok {
+			c.removed = append(c.removed, id)
 		}
 	}
+	return c
 }
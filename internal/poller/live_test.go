@@ -0,0 +1,38 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	sdkHttp "github.com/olafszymanski/int-sdk/http"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/olafszymanski/int-sdk/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollLiveEventsNotSynced(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		hash        = fmt.Sprintf(config.LiveEventsStorageKey, pb.SportType_BASKETBALL)
+		p           = newTestRedisPoller(t, doerFunc(func(request *sdkHttp.Request) (*sdkHttp.Response, error) {
+			return nil, fmt.Errorf("unexpected request: %s", request.URL)
+		}))
+	)
+	p.config.Live.RequestInterval = time.Millisecond
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.pollLiveEvents(ctx, logrus.NewEntry(logrus.New()), pb.SportType_BASKETBALL, make(chan *liveSetChange))
+	}()
+	// the classes haven't been polled yet, so the live events must not be seen as synced
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-errCh, context.Canceled)
+
+	_, err := p.storage.GetEvents(context.Background(), hash)
+	require.ErrorIs(t, err, storage.ErrNotFound)
+}
@@ -0,0 +1,45 @@
+package poller
+
+import "sync"
+
+// eventsLocks serializes the writes of the single stored events, the ones applying the updates
+// must not interleave with the ones suspending or resyncing the same event
+type eventsLocks struct {
+	lock  sync.Mutex
+	locks map[string]*eventLock
+}
+
+type eventLock struct {
+	sync.Mutex
+	// number of the writers holding or waiting for the lock, it's removed once there are none
+	refs int
+}
+
+func newEventsLocks() *eventsLocks {
+	return &eventsLocks{
+		locks: make(map[string]*eventLock),
+	}
+}
+
+// acquire locks the event and returns the function unlocking it
+func (l *eventsLocks) acquire(id string) func() {
+	l.lock.Lock()
+	el, ok := l.locks[id]
+	if !ok {
+		el = &eventLock{}
+		l.locks[id] = el
+	}
+	el.refs++
+	l.lock.Unlock()
+
+	el.Lock()
+	return func() {
+		el.Unlock()
+
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		if el.refs--; el.refs == 0 {
+			delete(l.locks, id)
+		}
+	}
+}
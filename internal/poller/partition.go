@@ -0,0 +1,158 @@
+package poller
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// bounds the number of parts a time period can be split into
+	maxPartitions = 32
+	// bounds how many times a timed out request is split while polling
+	maxSplitDepth = 8
+)
+
+// partitions learns how the time periods have to be split for the responses to stay within the limits,
+// the parts of the responses which turned out too large or too slow are split, while the small and fast neighbouring
+// ones are merged back, one step of either per polling
+type partitions struct {
+	lock  sync.Mutex
+	parts map[string][]*partition
+
+	maxResponseSize int
+	minTimeWindow   time.Duration
+	openSplit       time.Duration
+}
+
+type partition struct {
+	timePeriod timePeriod
+	observed   bool
+	// all of the responses of the last polling were well within the limits
+	small bool
+	// any of the responses of the last polling exceeded the limits
+	split bool
+}
+
+func newPartitions(maxResponseSize int, minTimeWindow, openSplit time.Duration) *partitions {
+	return &partitions{
+		parts:           make(map[string][]*partition),
+		maxResponseSize: maxResponseSize,
+		minTimeWindow:   minTimeWindow,
+		openSplit:       openSplit,
+	}
+}
+
+// get returns the parts the time period has to be polled in, applying what has been learned by the last polling
+func (p *partitions) get(key string, period timePeriod) []timePeriod {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	ps, ok := p.parts[key]
+	if !ok {
+		ps = []*partition{{timePeriod: period}}
+	}
+
+	res := make([]*partition, 0, len(ps)+1)
+	for i, pt := range ps {
+		// the parts split already and the ones left count towards the max partitions
+		if pt.split && len(res)+len(ps)-i < maxPartitions && pt.timePeriod.canSplit(p.minTimeWindow) {
+			a, b := pt.timePeriod.split(p.openSplit)
+			res = append(res, &partition{timePeriod: a}, &partition{timePeriod: b})
+			continue
+		}
+		res = append(res, pt)
+	}
+	for i := 0; i < len(res)-1; i++ {
+		a, b := res[i], res[i+1]
+		if a.observed && b.observed && a.small && b.small && !a.split && !b.split {
+			res[i] = &partition{timePeriod: mergeTimePeriods(a.timePeriod, b.timePeriod)}
+			res = append(res[:i+1], res[i+2:]...)
+			break
+		}
+	}
+
+	tps := make([]timePeriod, 0, len(res))
+	for _, pt := range res {
+		pt.observed, pt.small, pt.split = false, true, false
+		tps = append(tps, pt.timePeriod)
+	}
+	p.parts[key] = res
+	return tps
+}
+
+// observe records the response of the part of the time period
+func (p *partitions) observe(key string, part timePeriod, size int, took, timeout time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, pt := range p.parts[key] {
+		if pt.timePeriod != part {
+			continue
+		}
+		pt.observed = true
+		if size > p.maxResponseSize || took > timeout {
+			pt.split = true
+		}
+		if size > p.maxResponseSize/4 || took > timeout/4 {
+			pt.small = false
+		}
+		return
+	}
+}
+
+// markSplit makes the part of the time period split before the next polling
+func (p *partitions) markSplit(key string, part timePeriod) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, pt := range p.parts[key] {
+		if pt.timePeriod == part {
+			pt.observed, pt.split = true, true
+			return
+		}
+	}
+}
+
+func getPartitionKey(baseUrl string, timePeriod timePeriod) string {
+	return fmt.Sprintf("%s|%s|%s|%t", baseUrl, timePeriod.start, timePeriod.end, timePeriod.open)
+}
+
+// chunkClasses splits the classes into chunks short enough for the urls not to exceed the max length
+func chunkClasses(classes []byte, maxLength int) [][]byte {
+	var (
+		chunks = make([][]byte, 0)
+		chunk  = make([]string, 0)
+		length = 0
+	)
+	for _, c := range strings.Split(string(classes), ",") {
+		if c == "" {
+			continue
+		}
+		// a single class is always polled, even if it exceeds the max length on its own
+		if len(chunk) > 0 && length+1+len(c) > maxLength {
+			chunks = append(chunks, []byte(strings.Join(chunk, ",")))
+			chunk, length = make([]string, 0), 0
+		}
+		if len(chunk) > 0 {
+			length++
+		}
+		chunk = append(chunk, c)
+		length += len(c)
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, []byte(strings.Join(chunk, ",")))
+	}
+	return chunks
+}
+
+// splitClasses splits the classes in halves, it returns nil if there is only a single class
+func splitClasses(classes []byte) ([]byte, []byte) {
+	cls := strings.Split(string(classes), ",")
+	if len(cls) < 2 {
+		return nil, nil
+	}
+	h := len(cls) / 2
+	return []byte(strings.Join(cls[:h], ",")), []byte(strings.Join(cls[h:], ","))
+}
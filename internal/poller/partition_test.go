@@ -0,0 +1,299 @@
+package poller
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkHttp "github.com/olafszymanski/int-sdk/http"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitions(t *testing.T) {
+	const (
+		key     = "key"
+		timeout = time.Second
+	)
+	var (
+		period = timePeriod{start: 0, end: 4 * time.Hour}
+		halves = []timePeriod{{start: 0, end: 2 * time.Hour}, {start: 2 * time.Hour, end: 4 * time.Hour}}
+	)
+
+	tests := []struct {
+		name   string
+		period timePeriod
+		// polls the time period, observing its parts, before the last get
+		poll     func(p *partitions, parts []timePeriod)
+		pollings int
+		expected []timePeriod
+	}{
+		{
+			name:     "first polling",
+			period:   period,
+			expected: []timePeriod{period},
+		},
+		{
+			name:     "unobserved kept",
+			period:   period,
+			poll:     func(p *partitions, parts []timePeriod) {},
+			pollings: 2,
+			expected: []timePeriod{period},
+		},
+		{
+			name:   "too large split",
+			period: period,
+			poll: func(p *partitions, parts []timePeriod) {
+				p.observe(key, parts[0], 200, 0, timeout)
+			},
+			pollings: 1,
+			expected: halves,
+		},
+		{
+			name:   "too slow split",
+			period: period,
+			poll: func(p *partitions, parts []timePeriod) {
+				p.observe(key, parts[0], 10, 2*timeout, timeout)
+			},
+			pollings: 1,
+			expected: halves,
+		},
+		{
+			name:   "marked split",
+			period: period,
+			poll: func(p *partitions, parts []timePeriod) {
+				p.markSplit(key, parts[0])
+			},
+			pollings: 1,
+			expected: halves,
+		},
+		{
+			name:   "small adjacent merged",
+			period: period,
+			poll: func(p *partitions, parts []timePeriod) {
+				for _, pt := range parts {
+					if len(parts) == 1 {
+						p.observe(key, pt, 200, 0, timeout)
+						continue
+					}
+					p.observe(key, pt, 10, 0, timeout)
+				}
+			},
+			pollings: 2,
+			expected: []timePeriod{period},
+		},
+		{
+			name:   "not small not merged",
+			period: period,
+			poll: func(p *partitions, parts []timePeriod) {
+				for _, pt := range parts {
+					if len(parts) == 1 {
+						p.observe(key, pt, 200, 0, timeout)
+						continue
+					}
+					p.observe(key, pt, 50, 0, timeout)
+				}
+			},
+			pollings: 2,
+			expected: halves,
+		},
+		{
+			name:   "unobserved neighbour not merged",
+			period: period,
+			poll: func(p *partitions, parts []timePeriod) {
+				if len(parts) == 1 {
+					p.observe(key, parts[0], 200, 0, timeout)
+					return
+				}
+				p.observe(key, parts[0], 10, 0, timeout)
+			},
+			pollings: 2,
+			expected: halves,
+		},
+		{
+			name:   "min time window bound",
+			period: timePeriod{start: 0, end: 20 * time.Minute},
+			poll: func(p *partitions, parts []timePeriod) {
+				p.markSplit(key, parts[0])
+			},
+			pollings: 1,
+			expected: []timePeriod{{start: 0, end: 20 * time.Minute}},
+		},
+		{
+			name:   "open split at offset",
+			period: timePeriod{start: time.Hour, open: true},
+			poll: func(p *partitions, parts []timePeriod) {
+				p.markSplit(key, parts[0])
+			},
+			pollings: 1,
+			expected: []timePeriod{{start: time.Hour, end: 25 * time.Hour}, {start: 25 * time.Hour, open: true}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newPartitions(100, 15*time.Minute, 24*time.Hour)
+			parts := p.get(key, test.period)
+			for i := 0; i < test.pollings; i++ {
+				test.poll(p, parts)
+				parts = p.get(key, test.period)
+			}
+			require.Equal(t, test.expected, parts)
+		})
+	}
+}
+
+func TestPartitionsMaxPartitions(t *testing.T) {
+	var (
+		p      = newPartitions(100, 0, time.Hour)
+		period = timePeriod{open: true}
+		parts  = p.get("key", period)
+	)
+	for i := 0; i < 2*maxPartitions; i++ {
+		// the parts grow one by one short of the max partitions, then all of them exceed the limits at once
+		if len(parts) < maxPartitions-1 {
+			p.markSplit("key", parts[0])
+		} else {
+			for _, pt := range parts {
+				p.markSplit("key", pt)
+			}
+		}
+		parts = p.get("key", period)
+		require.LessOrEqual(t, len(parts), maxPartitions)
+	}
+	require.Len(t, parts, maxPartitions)
+}
+
+func TestFetchRequestEventsSplitDepth(t *testing.T) {
+	tests := []struct {
+		name          string
+		classes       string
+		timePeriod    timePeriod
+		minTimeWindow time.Duration
+		// requests sent until the splitting stops, the failing first half stops it
+		expected int64
+	}{
+		{
+			name:          "not splittable",
+			classes:       "1",
+			timePeriod:    timePeriod{start: 0, end: 15 * time.Minute},
+			minTimeWindow: 15 * time.Minute,
+			expected:      1,
+		},
+		{
+			name:          "split by classes",
+			classes:       "1,2",
+			timePeriod:    timePeriod{start: 0, end: 15 * time.Minute},
+			minTimeWindow: 15 * time.Minute,
+			expected:      2,
+		},
+		{
+			name:       "max split depth",
+			classes:    "1",
+			timePeriod: timePeriod{open: true},
+			expected:   maxSplitDepth + 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int64
+			p := newTestPoller(doerFunc(func(request *sdkHttp.Request) (*sdkHttp.Response, error) {
+				atomic.AddInt64(&requests, 1)
+				return &sdkHttp.Response{Status: http.StatusRequestTimeout}, nil
+			}))
+			p.config.Events.MinTimeWindow = test.minTimeWindow
+
+			_, _, err := p.fetchRequestEvents(context.Background(), &eventsRequest{
+				key:        "key",
+				baseUrl:    "https://example.com",
+				classes:    []byte(test.classes),
+				timePeriod: test.timePeriod,
+				timeout:    time.Second,
+			}, 0)
+			require.ErrorIs(t, err, ErrRequestTimeout)
+			require.Equal(t, test.expected, requests)
+		})
+	}
+}
+
+func TestChunkClasses(t *testing.T) {
+	tests := []struct {
+		name      string
+		classes   string
+		maxLength int
+		expected  [][]byte
+	}{
+		{
+			name:      "empty",
+			classes:   "",
+			maxLength: 10,
+			expected:  [][]byte{},
+		},
+		{
+			name:      "single chunk",
+			classes:   "1,2,3",
+			maxLength: 5,
+			expected:  [][]byte{[]byte("1,2,3")},
+		},
+		{
+			name:      "url length limit",
+			classes:   "1,2,3",
+			maxLength: 3,
+			expected:  [][]byte{[]byte("1,2"), []byte("3")},
+		},
+		{
+			name:      "class exceeding the limit",
+			classes:   "1,2345,6",
+			maxLength: 3,
+			expected:  [][]byte{[]byte("1"), []byte("2345"), []byte("6")},
+		},
+		{
+			name:      "empty classes skipped",
+			classes:   "1,,2,",
+			maxLength: 10,
+			expected:  [][]byte{[]byte("1,2")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, chunkClasses([]byte(test.classes), test.maxLength))
+		})
+	}
+}
+
+func TestSplitClasses(t *testing.T) {
+	tests := []struct {
+		name      string
+		classes   string
+		expectedA []byte
+		expectedB []byte
+	}{
+		{
+			name:    "single",
+			classes: "1",
+		},
+		{
+			name:      "even",
+			classes:   "1,2",
+			expectedA: []byte("1"),
+			expectedB: []byte("2"),
+		},
+		{
+			name:      "odd",
+			classes:   "1,2,3",
+			expectedA: []byte("1"),
+			expectedB: []byte("2,3"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := splitClasses([]byte(test.classes))
+			require.Equal(t, test.expectedA, a)
+			require.Equal(t, test.expectedB, b)
+		})
+	}
+}
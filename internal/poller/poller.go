@@ -5,62 +5,126 @@ import (
 	"fmt"
 
 	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/election"
+	"github.com/olafszymanski/int-ladbrokes/internal/limiter"
+	"github.com/olafszymanski/int-ladbrokes/internal/shard"
 	"github.com/olafszymanski/int-ladbrokes/internal/storage"
 	"github.com/olafszymanski/int-sdk/http"
 	"github.com/olafszymanski/int-sdk/integration/pb"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 )
 
-var ErrUnexpectedStatusCode = fmt.Errorf("unexpected status code")
+var (
+	ErrUnexpectedStatusCode = fmt.Errorf("unexpected status code")
+	ErrRequestTimeout       = fmt.Errorf("request timed out")
+)
+
+// prioritizer is implemented by the http clients sending the requests with priorities, e.g. the upstream limiter
+type prioritizer interface {
+	WithPriority(priority limiter.Priority) http.Doer
+}
+
+// sessioner is implemented by the http clients sending the requests of the same session through the same egress,
+// e.g. the proxy pool
+type sessioner interface {
+	WithSession(key string) http.Doer
+}
 
 type Poller struct {
 	config     *config.Config
 	httpClient http.Doer
-	storage    *storage.Storage
+	// sends the requests of the work yielding to the live one when the upstream budget is tight
+	lowPriorityClient http.Doer
+	storage           *storage.Storage
+	elector           *election.Elector
+	membership        *shard.Membership
+	partitions        *partitions
+	locks             *eventsLocks
+	refreshes         *refreshCooldowns
+	unhandled         *unhandledMarkets
+	transforms        *transformCache
 }
 
-func NewPoller(config *config.Config, httpClient http.Doer, storage *storage.Storage) (*Poller, error) {
+func NewPoller(config *config.Config, httpClient http.Doer, storage *storage.Storage, elector *election.Elector, membership *shard.Membership) (*Poller, error) {
 	return &Poller{
-		config:     config,
-		httpClient: httpClient,
-		storage:    storage,
+		config:            config,
+		httpClient:        httpClient,
+		lowPriorityClient: getLowPriorityClient(httpClient),
+		storage:           storage,
+		elector:           elector,
+		membership:        membership,
+		partitions: newPartitions(
+			config.Events.MaxResponseSize,
+			config.Events.MinTimeWindow,
+			config.Events.OpenTimeWindowSplit,
+		),
+		locks:      newEventsLocks(),
+		refreshes:  newRefreshCooldowns(config.Refresh.Cooldown),
+		unhandled:  newUnhandledMarkets(),
+		transforms: newTransformCache(config.Events.TransformCacheTTL),
 	}, nil
 }
 
+func getLowPriorityClient(httpClient http.Doer) http.Doer {
+	if p, ok := httpClient.(prioritizer); ok {
+		return p.WithPriority(limiter.PriorityLow)
+	}
+	return httpClient
+}
+
+// getSessionClient returns the client sending the requests of the session through the same egress, if supported
+func (p *Poller) getSessionClient(key string) http.Doer {
+	if s, ok := p.httpClient.(sessioner); ok {
+		return s.WithSession(key)
+	}
+	return p.httpClient
+}
+
+// Run polls the sport together with the other pollers of it, the classes and the results are polled only by the lease holder,
+// while the events are polled by every poller for its own shard of the classes.
+// It returns once all of the polling has stopped, after the context is done or any of the polling failed
 func (p *Poller) Run(ctx context.Context, sportType pb.SportType) error {
 	var (
 		logger = logrus.WithField("sport_type", sportType)
-		errCh  = make(chan error)
+		// the live events polling drives the updates subscriptions
+		changes = make(chan *liveSetChange, 1)
 	)
-	defer close(errCh)
+	g, ctx := errgroup.WithContext(ctx)
 
-	go func() {
-		if err := p.pollClasses(ctx, logger, sportType); err != nil {
-			errCh <- err
-			return
-		}
-	}()
-	go func() {
-		if err := p.pollLiveEvents(ctx, logger, sportType); err != nil {
-			errCh <- err
-			return
-		}
-	}()
-	go func() {
-		if err := p.pollPreMatchEvents(ctx, logger, sportType); err != nil {
-			errCh <- err
-			return
-		}
-	}()
-	go func() {
-		if err := p.pollUpdates(ctx, logger, sportType); err != nil {
-			errCh <- err
-			return
-		}
-	}()
+	g.Go(func() error {
+		return p.membership.Join(ctx, fmt.Sprintf(config.PollerMembersStorageKey, sportType))
+	})
+	g.Go(func() error {
+		return p.elector.Run(ctx, fmt.Sprintf(config.PollerLeaseStorageKey, sportType), func(ctx context.Context) error {
+			lg, ctx := errgroup.WithContext(ctx)
+			lg.Go(func() error {
+				return p.pollClasses(ctx, logger, sportType)
+			})
+			lg.Go(func() error {
+				return p.pollResults(ctx, logger, sportType)
+			})
+			return lg.Wait()
+		})
+	})
+	g.Go(func() error {
+		return p.pollLiveEvents(ctx, logger, sportType, changes)
+	})
+	g.Go(func() error {
+		return p.pollPreMatchEvents(ctx, logger, sportType)
+	})
+	g.Go(func() error {
+		return p.pollUpdates(ctx, logger, sportType, changes)
+	})
+	g.Go(func() error {
+		return p.watchStartingEvents(ctx, logger, sportType)
+	})
+	g.Go(func() error {
+		return p.pollFeaturedEvents(ctx, logger, sportType)
+	})
+	g.Go(func() error {
+		return p.handleRefreshRequests(ctx, logger, sportType)
+	})
 
-	if err := <-errCh; err != nil {
-		return err
-	}
-	return nil
+	return g.Wait()
 }
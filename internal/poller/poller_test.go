@@ -0,0 +1,51 @@
+package poller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/olafszymanski/int-ladbrokes/internal/broker"
+	"github.com/olafszymanski/int-ladbrokes/internal/storage"
+	sdkHttp "github.com/olafszymanski/int-sdk/http"
+	sdkStorage "github.com/olafszymanski/int-sdk/storage"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func getEventsResponse(t *testing.T, children ...json.RawMessage) []byte {
+	t.Helper()
+
+	rawData, err := json.Marshal(map[string]any{
+		"SSResponse": map[string]any{
+			"children": children,
+		},
+	})
+	require.NoError(t, err)
+	return rawData
+}
+
+// newTestRedisPoller returns the test poller with the storage backed by an in-memory Redis,
+// for the tests of the writes publishing the changes of the events
+func newTestRedisPoller(t *testing.T, httpClient sdkHttp.Doer) *Poller {
+	t.Helper()
+
+	p := newTestPoller(httpClient)
+	p.storage = newTestRedisStorage(t)
+	return p
+}
+
+func newTestRedisStorage(t *testing.T) *storage.Storage {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	r, err := sdkStorage.NewRedisStorage(context.Background(), mr.Addr(), "")
+	require.NoError(t, err)
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		r.Close()
+		rc.Close()
+	})
+	return storage.NewStorage(r, broker.NewBroker(rc), rc, 0)
+}
@@ -1,73 +1,225 @@
 package poller
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/delta"
 	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/olafszymanski/int-sdk/storage"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 )
 
 const preMatchFilter = "simpleFilter=event.isStarted:isFalse"
 
+// preMatchSchedule polls the pre-match events of a single time period on its own interval
+type preMatchSchedule struct {
+	timePeriod      timePeriod
+	requestInterval time.Duration
+	requestTimeout  time.Duration
+	// the events starting before the first time period are removed by it as well
+	first bool
+}
+
 func (p *Poller) pollPreMatchEvents(ctx context.Context, logger *logrus.Entry, sportType pb.SportType) error {
 	var (
-		startTime   time.Time
-		timePeriods = []timePeriod{
-			{-4 * time.Hour, 0},
-			{0, 8 * time.Hour},
-			{8 * time.Hour, 32 * time.Hour},
-			{32 * time.Hour, 0}, // last element does not have an end time
-		}
-		eventsCh   = make(chan []*pb.Event)
-		noEventsCh = make(chan struct{})
-		errCh      = make(chan error)
+		tps = p.config.PreMatch.TimePeriods
+		// the events are marked synced only once every time period has been polled
+		unsynced = &atomic.Int32{}
 	)
-	defer func() {
-		close(eventsCh)
-		close(noEventsCh)
-		close(errCh)
-	}()
-
-	for {
-		startTime = time.Now()
-
-		go func() {
-			u := fmt.Sprintf("%s&%s", eventsUrl, preMatchFilter)
-			evs, err := p.pollEvents(ctx, u, sportType, p.config.PreMatch.RequestTimeout, timePeriods)
-			if err != nil {
-				errCh <- fmt.Errorf("polling pre-match events failed: %w", err)
-				return
-			}
-			if len(evs) == 0 {
-				noEventsCh <- struct{}{}
-				return
-			}
-			eventsCh <- evs
-		}()
+	unsynced.Store(int32(len(tps)))
+	g, ctx := errgroup.WithContext(ctx)
 
-		select {
-		// if no events were polled, we want to retry after the request interval, this shouldn't happen for pre match events though
-		case <-noEventsCh:
+	for i, tp := range tps {
+		sch := &preMatchSchedule{
+			timePeriod:      newTimePeriod(tp),
+			requestInterval: tp.RequestInterval,
+			requestTimeout:  tp.RequestTimeout,
+			first:           i == 0,
+		}
+		g.Go(func() error {
+			return p.pollPreMatchTimePeriod(ctx, logger, sportType, sch, unsynced)
+		})
+	}
+	return g.Wait()
+}
+
+func (p *Poller) pollPreMatchTimePeriod(ctx context.Context, logger *logrus.Entry, sportType pb.SportType, sch *preMatchSchedule, unsynced *atomic.Int32) error {
+	var (
+		polled bool
+		// the hashes of the events last stored by the time period and the classes they were polled for
+		known    eventsHashes
+		owned    []byte
+		hash     = fmt.Sprintf(config.PreMatchEventsStorageKey, sportType)
+		liveHash = fmt.Sprintf(config.LiveEventsStorageKey, sportType)
+	)
+	logger = logger.WithFields(logrus.Fields{
+		"polling":           "pre-match",
+		"time_period_start": sch.timePeriod.start,
+		"time_period_end":   sch.timePeriod.end,
+	})
+
+	return schedule(ctx, logger, sch.requestInterval, func(ctx context.Context) error {
+		// the stored events are filtered by the same time period the requests are sent for,
+		// otherwise the events at its bounds would be seen as removed
+		now := time.Now()
+		u := fmt.Sprintf("%s&%s", eventsUrl, preMatchFilter)
+		pe, err := p.pollEvents(ctx, u, sportType, sch.requestTimeout, []timePeriod{sch.timePeriod}, now)
+		if err != nil {
+			return fmt.Errorf("polling pre-match events failed: %w", err)
+		}
+		// if the classes haven't been polled yet, we want to retry after the request interval
+		if pe == nil {
 			logger.Warn("no pre-match events polled")
-			<-time.After(p.config.PreMatch.RequestInterval - time.Since(startTime))
-		case evs := <-eventsCh:
-			logger.WithField("length", len(evs)).Debug("pre-match events polled")
+			return nil
+		}
+		logger.WithField("length", len(pe.events)).Debug("pre-match events polled")
 
-			hash := fmt.Sprintf(config.PreMatchEventsStorageKey, sportType)
-			if err := p.storage.RemoveMissingEvents(ctx, hash, evs); err != nil {
+		// the events which are off before being flagged as started are moved to the live ones right away
+		started, evs := splitStartedEvents(pe.events)
+		if err := p.startEvents(ctx, sportType, started); err != nil {
+			return fmt.Errorf("failed to start pre-match events: %s", err)
+		}
+
+		// the last stored events are loaded again once the owned classes have changed, the other pollers might have
+		// stored the events of the classes taken over
+		if known == nil || !bytes.Equal(owned, pe.owned) {
+			if known, err = p.getPreMatchEventsHashes(ctx, hash, pe, sch, now); err != nil {
+				return fmt.Errorf("failed to get pre-match events hashes: %s", err)
+			}
+			owned = pe.owned
+		}
+		changed, missIds, err := known.getChanges(evs, pe.classes)
+		if err != nil {
+			return fmt.Errorf("failed to hash pre-match events: %s", err)
+		}
+		// only the changed and the missing events are read, the rest of them are stored already
+		ids := append(getEventsIds(changed), missIds...)
+		curr, err := p.storage.GetEventsByIds(ctx, hash, ids)
+		if err != nil {
+			return fmt.Errorf("failed to get current pre-match events: %s", err)
+		}
+		// the events moved to the other time periods are compared against by them, they would be seen as removed
+		curr = filterTimePeriodEvents(curr, sch, now)
+
+		// the events which have just started are kept until the live polling moves them, so that they never go missing,
+		// the rest of the missing ones are removed
+		pending, miss := splitPendingStartEvents(curr, getMissingEventsIds(curr, evs), p.config.Lifecycle.StartGrace)
+		if len(miss) > 0 {
+			// started events not polled as live disappear from the pre-match ones, their last state has to be captured before removing them
+			if err := p.captureStartedClosingLines(ctx, sportType, miss); err != nil {
+				return fmt.Errorf("failed to capture closing lines: %s", err)
+			}
+			if err := p.storage.DeleteEvents(ctx, hash, miss); err != nil {
 				return fmt.Errorf("failed to remove missing pre-match events: %s", err)
 			}
-			if err := p.storage.StoreEvents(ctx, hash, evs); err != nil {
-				return fmt.Errorf("failed to store pre-match events: %s", err)
+		}
+		// the events moved to the live ones in the meantime must not be stored back
+		stored, err := p.storage.StoreEventsExcluding(ctx, hash, liveHash, changed)
+		if err != nil {
+			return fmt.Errorf("failed to store pre-match events: %s", err)
+		}
+		if err := p.storage.StoreEventsClasses(ctx, hash, getStoredEventsClasses(pe.classes, stored)); err != nil {
+			return fmt.Errorf("failed to store pre-match events classes: %s", err)
+		}
+		if err := p.storage.PublishDeltas(ctx, fmt.Sprintf(config.EventsDeltasChannelKey, hash), delta.GetEventsDeltas(curr, append(stored, pending...))); err != nil {
+			return fmt.Errorf("failed to publish pre-match events deltas: %s", err)
+		}
+		// the pending events are kept until they are moved, the rest of the read ones are forgotten unless stored
+		known.delete(getMissingIds(ids, pending))
+		if err := known.put(stored, pe.classes); err != nil {
+			return fmt.Errorf("failed to hash pre-match events: %s", err)
+		}
+
+		if !polled {
+			polled = true
+			unsynced.Add(-1)
+		}
+		if unsynced.Load() == 0 {
+			if err := p.storage.MarkEventsSynced(ctx, hash); err != nil {
+				return fmt.Errorf("failed to mark pre-match events synced: %s", err)
+			}
+		}
+		return nil
+	})
+}
+
+// getPreMatchEventsHashes returns the hashes of the stored pre-match events owned by the instance within the time period
+func (p *Poller) getPreMatchEventsHashes(ctx context.Context, hash string, pe *polledEvents, schedule *preMatchSchedule, now time.Time) (eventsHashes, error) {
+	evs, err := p.storage.GetEvents(ctx, hash)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+	cls, err := p.storage.GetEventsClasses(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	// the events of the other pollers and time periods must not be compared against, they would be seen as removed
+	return newEventsHashes(filterTimePeriodEvents(filterOwnedEvents(evs, pe.owns(cls)), schedule, now), cls)
+}
+
+// splitPendingStartEvents splits the missing events into the ones which have started within the grace period
+// and the ids of the rest of them
+func splitPendingStartEvents(events []*pb.Event, missingIds []string, grace time.Duration) ([]*pb.Event, []string) {
+	var (
+		now     = time.Now()
+		pending = make([]*pb.Event, 0)
+		miss    = make([]string, 0, len(missingIds))
+		evs     = make(map[string]*pb.Event, len(events))
+	)
+	for _, e := range events {
+		evs[e.ExternalId] = e
+	}
+	for _, id := range missingIds {
+		if e, ok := evs[id]; ok {
+			st := e.StartTime.AsTime()
+			if !st.After(now) && now.Sub(st) < grace {
+				pending = append(pending, e)
+				continue
 			}
-			<-time.After(p.config.PreMatch.RequestInterval - time.Since(startTime))
-		case <-time.After(p.config.PreMatch.RequestInterval):
-			logger.Warn("pre-match events polling took longer than expected")
-		case err := <-errCh:
-			return err
+		}
+		miss = append(miss, id)
+	}
+	return pending, miss
+}
+
+// getMissingIds returns the ids missing from the events
+func getMissingIds(ids []string, events []*pb.Event) []string {
+	evs := make(map[string]struct{}, len(events))
+	for _, e := range events {
+		evs[e.ExternalId] = struct{}{}
+	}
+	miss := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := evs[id]; !ok {
+			miss = append(miss, id)
+		}
+	}
+	return miss
+}
+
+func getStoredEventsClasses(classes map[string]string, stored []*pb.Event) map[string]string {
+	cls := make(map[string]string, len(stored))
+	for _, e := range stored {
+		if c, ok := classes[e.ExternalId]; ok {
+			cls[e.ExternalId] = c
+		}
+	}
+	return cls
+}
+
+func filterTimePeriodEvents(events []*pb.Event, schedule *preMatchSchedule, now time.Time) []*pb.Event {
+	evs := make([]*pb.Event, 0, len(events))
+	for _, e := range events {
+		if schedule.timePeriod.contains(e.StartTime.AsTime(), now, schedule.first) {
+			evs = append(evs, e)
 		}
 	}
+	return evs
 }
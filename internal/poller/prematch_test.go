@@ -0,0 +1,151 @@
+package poller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/transform"
+	sdkHttp "github.com/olafszymanski/int-sdk/http"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/olafszymanski/int-sdk/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestPollPreMatchEventsSynced(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		hash        = fmt.Sprintf(config.PreMatchEventsStorageKey, pb.SportType_BASKETBALL)
+		release     = make(chan struct{})
+		polled      = &atomic.Int32{}
+		p           = newTestRedisPoller(t, doerFunc(func(request *sdkHttp.Request) (*sdkHttp.Response, error) {
+			// the open time period is not polled until released, while the closed one keeps polling
+			if !strings.Contains(request.URL, "lessThan") {
+				<-release
+			} else {
+				polled.Add(1)
+			}
+			return &sdkHttp.Response{Status: 200, Body: getEventsResponse(t)}, nil
+		}))
+	)
+	p.membership = newTestMembership(t)
+	p.config.PreMatch.TimePeriods = []config.TimePeriod{
+		{Start: 0, End: time.Hour, RequestInterval: 10 * time.Millisecond, RequestTimeout: time.Second},
+		{Start: time.Hour, Open: true, RequestInterval: 10 * time.Millisecond, RequestTimeout: time.Second},
+	}
+	require.NoError(t, p.storage.StoreClasses(ctx, fmt.Sprintf(classesStorageKey, pb.SportType_BASKETBALL), []byte("1")))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.pollPreMatchEvents(ctx, logrus.NewEntry(logrus.New()), pb.SportType_BASKETBALL)
+	}()
+
+	// a missing hash is told apart from an empty one only once the events are synced
+	require.Eventually(t, func() bool {
+		return polled.Load() >= 3
+	}, time.Second, time.Millisecond)
+	_, err := p.storage.GetEvents(ctx, hash)
+	require.ErrorIs(t, err, storage.ErrNotFound)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		evs, err := p.storage.GetEvents(ctx, hash)
+		return err == nil && len(evs) == 0
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, <-errCh, context.Canceled)
+}
+
+func TestFilterTimePeriodEvents(t *testing.T) {
+	var (
+		now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		sch = &preMatchSchedule{timePeriod: timePeriod{start: 0, end: time.Hour}}
+		evs = []*pb.Event{
+			{ExternalId: "before", StartTime: timestamppb.New(now.Add(-time.Nanosecond))},
+			{ExternalId: "start", StartTime: timestamppb.New(now)},
+			{ExternalId: "end", StartTime: timestamppb.New(now.Add(time.Hour - time.Nanosecond))},
+			{ExternalId: "after", StartTime: timestamppb.New(now.Add(time.Hour))},
+		}
+	)
+
+	// the bounds are the ones of the requests sent at the same time
+	u := getUrl(eventsUrl, []byte("1"), &sch.timePeriod, now)
+	require.Contains(t, u, "greaterThanOrEqual:2024-05-01T12:00:00Z")
+	require.Contains(t, u, "lessThan:2024-05-01T13:00:00Z")
+	require.Equal(t, []*pb.Event{evs[1], evs[2]}, filterTimePeriodEvents(evs, sch, now))
+
+	// the first time period keeps the events which started already
+	sch.first = true
+	require.Equal(t, []*pb.Event{evs[0], evs[1], evs[2]}, filterTimePeriodEvents(evs, sch, now))
+}
+
+func TestPollPreMatchEventsHashes(t *testing.T) {
+	rawData, err := os.ReadFile("../transform/testdata/basketball/success.json")
+	require.NoError(t, err)
+	// the event is not started yet, otherwise it would be moved to the live ones
+	raw, err := transform.SplitEvents(bytes.Replace(rawData, []byte(`"isStarted": "true"`), []byte(`"isStarted": "false"`), 1))
+	require.NoError(t, err)
+	require.Len(t, raw, 1)
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		hash        = fmt.Sprintf(config.PreMatchEventsStorageKey, pb.SportType_BASKETBALL)
+		second      = json.RawMessage(bytes.ReplaceAll(raw[0], []byte("243810572"), []byte("243810573")))
+		response    = &atomic.Pointer[[]byte]{}
+		requests    = &atomic.Int32{}
+		p           = newTestRedisPoller(t, doerFunc(func(request *sdkHttp.Request) (*sdkHttp.Response, error) {
+			requests.Add(1)
+			return &sdkHttp.Response{Status: 200, Body: *response.Load()}, nil
+		}))
+	)
+	both := getEventsResponse(t, raw[0], second)
+	response.Store(&both)
+	p.membership = newTestMembership(t)
+	p.config.PreMatch.TimePeriods = []config.TimePeriod{
+		{Start: 0, End: time.Hour, RequestInterval: 10 * time.Millisecond, RequestTimeout: time.Second},
+	}
+	require.NoError(t, p.storage.StoreClasses(ctx, fmt.Sprintf(classesStorageKey, pb.SportType_BASKETBALL), []byte("25")))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.pollPreMatchEvents(ctx, logrus.NewEntry(logrus.New()), pb.SportType_BASKETBALL)
+	}()
+	require.Eventually(t, func() bool {
+		evs, err := p.storage.GetEvents(ctx, hash)
+		return err == nil && len(evs) == 2
+	}, time.Second, time.Millisecond)
+
+	// the unchanged events are compared against their hashes in memory, they are neither read nor written again
+	ev, err := p.storage.GetEvent(ctx, hash, "243810572")
+	require.NoError(t, err)
+	ev.Name = "changed"
+	require.NoError(t, p.storage.StoreEvent(ctx, hash, ev))
+	n := requests.Load()
+	require.Eventually(t, func() bool {
+		return requests.Load() >= n+2
+	}, time.Second, time.Millisecond)
+	ev, err = p.storage.GetEvent(ctx, hash, "243810572")
+	require.NoError(t, err)
+	require.Equal(t, "changed", ev.Name)
+
+	// the missing ones are removed
+	first := getEventsResponse(t, raw[0])
+	response.Store(&first)
+	require.Eventually(t, func() bool {
+		evs, err := p.storage.GetEvents(ctx, hash)
+		return err == nil && len(evs) == 1 && evs[0].ExternalId == "243810572"
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, <-errCh, context.Canceled)
+}
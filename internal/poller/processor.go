@@ -0,0 +1,174 @@
+package poller
+
+import (
+	"context"
+	"sync"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/mapping"
+	"github.com/olafszymanski/int-ladbrokes/internal/transform"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// updatesProcessor applies the updates with a fixed pool of workers, the updates of a single event are applied
+// serially in the order they were received, while the ones still queued are coalesced into a single update.
+// The number of events with queued updates is bounded, enqueueing blocks once it is reached
+type updatesProcessor struct {
+	lock sync.Mutex
+	// queued update of every event, coalesced
+	pending map[string]*transform.Update
+	// events whose update is being applied
+	active map[string]struct{}
+	// events with queued updates which are not being applied, every event is in it at most once
+	ready chan string
+	// every event with queued updates holds a slot
+	slots chan struct{}
+
+	workers int
+	apply   func(ctx context.Context, id string, update *transform.Update) error
+	depth   prometheus.Gauge
+}
+
+func newUpdatesProcessor(workers, maxDepth int, depth prometheus.Gauge, apply func(ctx context.Context, id string, update *transform.Update) error) *updatesProcessor {
+	return &updatesProcessor{
+		pending: make(map[string]*transform.Update),
+		active:  make(map[string]struct{}),
+		// there are never more ready events than the slots, so sending to it never blocks
+		ready:   make(chan string, maxDepth),
+		slots:   make(chan struct{}, maxDepth),
+		workers: workers,
+		apply:   apply,
+		depth:   depth,
+	}
+}
+
+// run runs the workers until the context is done or applying any of the updates failed
+func (u *updatesProcessor) run(ctx context.Context) error {
+	var (
+		wg    = sync.WaitGroup{}
+		errCh = make(chan error, u.workers)
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	wg.Add(u.workers)
+	for i := 0; i < u.workers; i++ {
+		go func() {
+			defer wg.Done()
+			if err := u.work(ctx); err != nil {
+				errCh <- err
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errCh:
+		return err
+	default:
+		return ctx.Err()
+	}
+}
+
+// enqueue queues the update of the event, coalescing it with the event's update queued already,
+// it blocks until there is a free slot if the event doesn't have any queued update
+func (u *updatesProcessor) enqueue(ctx context.Context, id string, update *transform.Update) error {
+	if u.coalesce(id, update) {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case u.slots <- struct{}{}:
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if p, ok := u.pending[id]; ok {
+		// another update of the event has been queued while waiting for the slot
+		u.pending[id] = mergeUpdates(p, update)
+		<-u.slots
+		return nil
+	}
+	u.pending[id] = update
+	u.depth.Set(float64(len(u.pending)))
+	if _, ok := u.active[id]; !ok {
+		u.ready <- id
+	}
+	return nil
+}
+
+func (u *updatesProcessor) coalesce(id string, update *transform.Update) bool {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	p, ok := u.pending[id]
+	if ok {
+		u.pending[id] = mergeUpdates(p, update)
+	}
+	return ok
+}
+
+func (u *updatesProcessor) work(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case id := <-u.ready:
+			u.lock.Lock()
+			update := u.pending[id]
+			delete(u.pending, id)
+			u.active[id] = struct{}{}
+			u.depth.Set(float64(len(u.pending)))
+			u.lock.Unlock()
+			<-u.slots
+
+			err := u.apply(ctx, id, update)
+
+			u.lock.Lock()
+			delete(u.active, id)
+			// the updates queued while applying are applied after it, by any of the workers
+			if _, ok := u.pending[id]; ok {
+				u.ready <- id
+			}
+			u.lock.Unlock()
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// mergeUpdates merges the next update into the previous one, the prices of the same outcome are replaced
+// by the latest ones, while the rest of the updates are kept in the order they were received
+func mergeUpdates(previous, next *transform.Update) *transform.Update {
+	res := &transform.Update{
+		Data:             make(map[mapping.UpdateType][]*transform.UpdateData, len(previous.Data)),
+		RequestBodyParts: next.RequestBodyParts,
+	}
+	for t, ds := range previous.Data {
+		res.Data[t] = append([]*transform.UpdateData{}, ds...)
+	}
+	for t, ds := range next.Data {
+		for _, d := range ds {
+			if t == mapping.PriceUpdateType && replaceUpdateData(res.Data[t], d) {
+				continue
+			}
+			res.Data[t] = append(res.Data[t], d)
+		}
+	}
+	return res
+}
+
+func replaceUpdateData(data []*transform.UpdateData, update *transform.UpdateData) bool {
+	for i, d := range data {
+		if d.ID == update.ID {
+			data[i] = update
+			return true
+		}
+	}
+	return false
+}
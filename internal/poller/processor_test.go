@@ -0,0 +1,106 @@
+package poller
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/mapping"
+	"github.com/olafszymanski/int-ladbrokes/internal/transform"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestUpdatesProcessor(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	var (
+		lock    = sync.Mutex{}
+		active  = make(map[string]bool)
+		applied = make(map[string][]string)
+		release = make(chan struct{})
+		count   atomic.Int32
+	)
+	u := newUpdatesProcessor(4, 8, prometheus.NewGauge(prometheus.GaugeOpts{Name: "depth"}), func(ctx context.Context, id string, update *transform.Update) error {
+		lock.Lock()
+		require.False(t, active[id], "updates of a single event must be applied serially")
+		active[id] = true
+		lock.Unlock()
+
+		// the first update of every event is held, so that the next ones get coalesced
+		if count.Add(1) <= 2 {
+			<-release
+		}
+
+		lock.Lock()
+		active[id] = false
+		for _, d := range update.Data[mapping.PriceUpdateType] {
+			applied[id] = append(applied[id], string(d.RawData))
+		}
+		lock.Unlock()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- u.run(ctx)
+	}()
+
+	for _, id := range []string{"1", "2"} {
+		require.NoError(t, u.enqueue(ctx, id, newPriceUpdate("10", "a")))
+	}
+	require.Eventually(t, func() bool {
+		return count.Load() == 2
+	}, time.Second, time.Millisecond)
+	for _, id := range []string{"1", "2"} {
+		require.NoError(t, u.enqueue(ctx, id, newPriceUpdate("10", "b")))
+		require.NoError(t, u.enqueue(ctx, id, newPriceUpdate("11", "c")))
+		require.NoError(t, u.enqueue(ctx, id, newPriceUpdate("10", "d")))
+	}
+	close(release)
+
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(applied["1"]) == 3 && len(applied["2"]) == 3
+	}, time.Second, time.Millisecond)
+	lock.Lock()
+	for _, id := range []string{"1", "2"} {
+		// the price of the outcome 10 is coalesced into the latest one, keeping its position
+		require.Equal(t, []string{"a", "d", "c"}, applied[id])
+	}
+	lock.Unlock()
+
+	cancel()
+	require.ErrorIs(t, <-errCh, context.Canceled)
+}
+
+func TestUpdatesProcessorBackpressure(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	u := newUpdatesProcessor(1, 1, prometheus.NewGauge(prometheus.GaugeOpts{Name: "depth"}), func(ctx context.Context, id string, update *transform.Update) error {
+		return nil
+	})
+
+	// without the workers running, the second event can't be queued
+	require.NoError(t, u.enqueue(context.Background(), "1", newPriceUpdate("10", "a")))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, u.enqueue(ctx, "2", newPriceUpdate("20", "a")), context.DeadlineExceeded)
+	// the event's updates are coalesced though
+	require.NoError(t, u.enqueue(context.Background(), "1", newPriceUpdate("10", "b")))
+}
+
+func newPriceUpdate(outcomeId, price string) *transform.Update {
+	return &transform.Update{
+		Data: map[mapping.UpdateType][]*transform.UpdateData{
+			mapping.PriceUpdateType: {
+				{ID: outcomeId, RawData: []byte(price)},
+			},
+		},
+	}
+}
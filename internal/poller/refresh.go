@@ -0,0 +1,304 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/delta"
+	"github.com/olafszymanski/int-ladbrokes/internal/mapping"
+	"github.com/olafszymanski/int-ladbrokes/internal/metrics"
+	"github.com/olafszymanski/int-ladbrokes/internal/transform"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/olafszymanski/int-sdk/storage"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// the updates referenced markets or selections missing from the stored event
+	refreshUnknownTrigger  = "unknown"
+	refreshFeaturedTrigger = "featured"
+	refreshAdminTrigger    = "admin"
+)
+
+// refreshCooldowns limits how often the events are refreshed on the updates of their unknown markets
+type refreshCooldowns struct {
+	lock     sync.Mutex
+	last     map[string]time.Time
+	cooldown time.Duration
+}
+
+func newRefreshCooldowns(cooldown time.Duration) *refreshCooldowns {
+	return &refreshCooldowns{
+		last:     make(map[string]time.Time),
+		cooldown: cooldown,
+	}
+}
+
+// allow returns true and starts the cooldown of the event unless it's cooling down already
+func (r *refreshCooldowns) allow(id string, now time.Time) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if t, ok := r.last[id]; ok && now.Sub(t) < r.cooldown {
+		return false
+	}
+	// the expired cooldowns are dropped, so that the ones of the ended events don't pile up
+	for i, t := range r.last {
+		if now.Sub(t) >= r.cooldown {
+			delete(r.last, i)
+		}
+	}
+	r.last[id] = now
+	return true
+}
+
+// unhandledMarkets keeps the ids of the markets of the unhandled types and of their outcomes by the events,
+// they are never stored, so their updates would trigger a refresh every time otherwise. They are learned
+// from the snapshots of the events and forgotten once the updates subscriptions of the events stop
+type unhandledMarkets struct {
+	lock sync.Mutex
+	ids  map[string]map[string]struct{}
+}
+
+func newUnhandledMarkets() *unhandledMarkets {
+	return &unhandledMarkets{
+		ids: make(map[string]map[string]struct{}),
+	}
+}
+
+func (u *unhandledMarkets) set(id string, ids map[string]struct{}) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	u.ids[id] = ids
+}
+
+// get returns the ids of the unhandled markets of the event and of their outcomes, it must not be modified
+func (u *unhandledMarkets) get(id string) map[string]struct{} {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	return u.ids[id]
+}
+
+func (u *unhandledMarkets) forget(id string) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	delete(u.ids, id)
+}
+
+// refreshUnknownEvents refreshes the live events whose updates referenced unknown markets until the context is done,
+// outside of the updates processor, so that the snapshot fetches hold back neither the workers nor the events updates
+func (p *Poller) refreshUnknownEvents(ctx context.Context, logger *logrus.Entry, sportType pb.SportType, hash string, requests <-chan string) error {
+	refreshes := metrics.EventsRefreshes.WithLabelValues(sportType.String(), refreshUnknownTrigger)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case id := <-requests:
+			if err := p.refreshEvent(ctx, hash, id); err != nil {
+				logger.WithError(err).WithField("event_external_id", id).Warn("failed to refresh event")
+				continue
+			}
+			refreshes.Inc()
+		}
+	}
+}
+
+// pollFeaturedEvents refreshes the owned live events of the featured leagues on the interval, so that their markets
+// opened in the meantime are picked up even if none of the updates referenced them
+func (p *Poller) pollFeaturedEvents(ctx context.Context, logger *logrus.Entry, sportType pb.SportType) error {
+	if len(p.config.Refresh.FeaturedLeagues) == 0 {
+		return nil
+	}
+	var (
+		hash      = fmt.Sprintf(config.LiveEventsStorageKey, sportType)
+		leagues   = make(map[string]struct{}, len(p.config.Refresh.FeaturedLeagues))
+		refreshes = metrics.EventsRefreshes.WithLabelValues(sportType.String(), refreshFeaturedTrigger)
+	)
+	for _, l := range p.config.Refresh.FeaturedLeagues {
+		leagues[l] = struct{}{}
+	}
+	logger = logger.WithField("polling", "featured events")
+
+	return schedule(ctx, logger, p.config.Refresh.FeaturedInterval, func(ctx context.Context) error {
+		idx, err := p.storage.GetEventsIndex(ctx, hash)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil
+			}
+			return fmt.Errorf("failed to get live events index: %s", err)
+		}
+		owns, err := p.getLiveEventsOwner(ctx, sportType)
+		if err != nil {
+			return err
+		}
+
+		for id, i := range idx {
+			if _, ok := leagues[i.League]; !ok || !owns(id) {
+				continue
+			}
+			// a single failed refresh must not hold back the other events
+			if err := p.refreshEvent(ctx, hash, id); err != nil {
+				logger.WithError(err).WithField("event_external_id", id).Warn("failed to refresh event")
+				// the rest of the events would fail the same way, they are refreshed on the next run
+				if isUpstreamUnavailable(err) {
+					break
+				}
+				continue
+			}
+			refreshes.Inc()
+		}
+		return nil
+	})
+}
+
+// handleRefreshRequests refreshes the owned live events requested through the admin API until the context is done
+func (p *Poller) handleRefreshRequests(ctx context.Context, logger *logrus.Entry, sportType pb.SportType) error {
+	var (
+		hash      = fmt.Sprintf(config.LiveEventsStorageKey, sportType)
+		refreshes = metrics.EventsRefreshes.WithLabelValues(sportType.String(), refreshAdminTrigger)
+	)
+	sub, err := p.storage.SubscribeEventsRefreshes(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to events refreshes: %s", err)
+	}
+	defer sub.Close()
+
+	for {
+		raw, err := sub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to receive event refresh: %s", err)
+		}
+		id := string(raw)
+		owns, err := p.getLiveEventsOwner(ctx, sportType)
+		if err != nil {
+			return err
+		}
+		// every poller of the sport receives the request, only the one polling the event refreshes it
+		if !owns(id) {
+			continue
+		}
+		logger.WithField("event_external_id", id).Info("refreshing event on request")
+		if err := p.refreshEvent(ctx, hash, id); err != nil {
+			logger.WithError(err).WithField("event_external_id", id).Warn("failed to refresh event")
+			continue
+		}
+		refreshes.Inc()
+	}
+}
+
+// getLiveEventsOwner returns the function telling whether the stored live event is owned by the instance
+func (p *Poller) getLiveEventsOwner(ctx context.Context, sportType pb.SportType) (func(id string) bool, error) {
+	sh, err := p.getShard(ctx, sportType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shard: %s", err)
+	}
+	cls, err := p.storage.GetEventsClasses(ctx, fmt.Sprintf(config.LiveEventsStorageKey, sportType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get live events classes: %s", err)
+	}
+	return getEventsOwner(sh, cls), nil
+}
+
+// refreshEvent merges the full snapshot of the event into the stored one, with the lock of the event held
+func (p *Poller) refreshEvent(ctx context.Context, hash, id string) error {
+	unlock := p.locks.acquire(id)
+	defer unlock()
+	return p.mergeEventSnapshot(ctx, hash, id)
+}
+
+// mergeEventSnapshot fetches the full snapshot of the event and merges it into the stored event,
+// the caller must hold the lock of the event
+func (p *Poller) mergeEventSnapshot(ctx context.Context, hash, id string) error {
+	snap, err := p.fetchEvent(id, p.config.Refresh.RequestTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to fetch event snapshot: %w", err)
+	}
+	// the event is gone, the live events polling is about to remove it
+	if snap == nil {
+		return nil
+	}
+	curr, err := p.storage.GetEvent(ctx, hash, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get event from storage: %s", err)
+	}
+	ev := mergeEvent(curr, snap)
+	if proto.Equal(curr, ev) {
+		return nil
+	}
+	if err := p.storage.StoreEvent(ctx, hash, ev); err != nil {
+		return fmt.Errorf("failed to save event: %s", err)
+	}
+	if err := p.storage.PublishDeltas(ctx, fmt.Sprintf(config.EventsDeltasChannelKey, hash), delta.GetEventDeltas(curr, ev)); err != nil {
+		return fmt.Errorf("failed to publish event deltas: %s", err)
+	}
+	return nil
+}
+
+// mergeEvent merges the snapshot into the stored event, its markets replace the stored ones with the same ids,
+// while the stored ones missing from it are kept. The outcomes of the suspended event are kept unavailable,
+// as only its updates feed can tell it's healthy again
+func mergeEvent(stored, snapshot *pb.Event) *pb.Event {
+	ev, _ := proto.Clone(snapshot).(*pb.Event)
+	ids := make(map[string]struct{}, len(ev.Markets))
+	for _, m := range ev.Markets {
+		ids[m.ExternalId] = struct{}{}
+	}
+	for _, m := range stored.Markets {
+		if _, ok := ids[m.ExternalId]; !ok {
+			ev.Markets = append(ev.Markets, proto.Clone(m).(*pb.Market))
+		}
+	}
+	if isEventSuspended(stored) {
+		suspendOutcomes(ev)
+	}
+	return ev
+}
+
+// hasUnknownMarkets checks whether the update references any of the markets or selections missing from the event,
+// other than the ones of the unhandled markets
+func hasUnknownMarkets(update *transform.Update, event *pb.Event, unhandled map[string]struct{}) bool {
+	var (
+		markets  = make(map[string]struct{}, len(event.Markets))
+		outcomes = make(map[string]struct{})
+	)
+	for _, m := range event.Markets {
+		markets[m.ExternalId] = struct{}{}
+		for _, o := range m.Outcomes {
+			outcomes[o.ExternalId] = struct{}{}
+		}
+	}
+	isUnknown := func(known map[string]struct{}, id string) bool {
+		if _, ok := known[id]; ok {
+			return false
+		}
+		_, ok := unhandled[id]
+		return !ok
+	}
+	for _, data := range update.Data[mapping.MarketUpdateType] {
+		if isUnknown(markets, data.ID) {
+			return true
+		}
+	}
+	for _, t := range []mapping.UpdateType{mapping.SelectionUpdateType, mapping.PriceUpdateType} {
+		for _, data := range update.Data[t] {
+			if isUnknown(outcomes, data.ID) {
+				return true
+			}
+		}
+	}
+	return false
+}
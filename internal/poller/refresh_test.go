@@ -0,0 +1,169 @@
+package poller
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/mapping"
+	"github.com/olafszymanski/int-ladbrokes/internal/transform"
+	sdkHttp "github.com/olafszymanski/int-sdk/http"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   *pb.Event
+		snapshot *pb.Event
+		expected *pb.Event
+	}{
+		{
+			name: "new and changed markets",
+			stored: &pb.Event{
+				ExternalId: "1",
+				Markets: []*pb.Market{
+					{ExternalId: "10", Outcomes: []*pb.Outcome{{ExternalId: "100", IsAvailable: true}}},
+					{ExternalId: "11", Outcomes: []*pb.Outcome{{ExternalId: "110", IsAvailable: true}}},
+				},
+			},
+			snapshot: &pb.Event{
+				ExternalId: "1",
+				Markets: []*pb.Market{
+					{ExternalId: "10", Outcomes: []*pb.Outcome{{ExternalId: "100", IsAvailable: false}}},
+					{ExternalId: "12", Outcomes: []*pb.Outcome{{ExternalId: "120", IsAvailable: true}}},
+				},
+			},
+			expected: &pb.Event{
+				ExternalId: "1",
+				Markets: []*pb.Market{
+					{ExternalId: "10", Outcomes: []*pb.Outcome{{ExternalId: "100", IsAvailable: false}}},
+					{ExternalId: "12", Outcomes: []*pb.Outcome{{ExternalId: "120", IsAvailable: true}}},
+					{ExternalId: "11", Outcomes: []*pb.Outcome{{ExternalId: "110", IsAvailable: true}}},
+				},
+			},
+		},
+		{
+			name: "suspended event",
+			stored: &pb.Event{
+				ExternalId: "1",
+				Markets: []*pb.Market{
+					{ExternalId: "10", Outcomes: []*pb.Outcome{{ExternalId: "100", IsAvailable: false}}},
+				},
+			},
+			snapshot: &pb.Event{
+				ExternalId: "1",
+				Markets: []*pb.Market{
+					{ExternalId: "10", Outcomes: []*pb.Outcome{{ExternalId: "100", IsAvailable: true}}},
+					{ExternalId: "12", Outcomes: []*pb.Outcome{{ExternalId: "120", IsAvailable: true}}},
+				},
+			},
+			expected: &pb.Event{
+				ExternalId: "1",
+				Markets: []*pb.Market{
+					{ExternalId: "10", Outcomes: []*pb.Outcome{{ExternalId: "100", IsAvailable: false}}},
+					{ExternalId: "12", Outcomes: []*pb.Outcome{{ExternalId: "120", IsAvailable: false}}},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ev := mergeEvent(test.stored, test.snapshot)
+			require.Equal(t, test.expected.String(), ev.String())
+		})
+	}
+}
+
+func TestHasUnknownMarkets(t *testing.T) {
+	ev := &pb.Event{
+		Markets: []*pb.Market{
+			{ExternalId: "10", Outcomes: []*pb.Outcome{{ExternalId: "100"}}},
+		},
+	}
+	newUpdate := func(t mapping.UpdateType, id string) *transform.Update {
+		return &transform.Update{
+			Data: map[mapping.UpdateType][]*transform.UpdateData{
+				t: {{ID: id}},
+			},
+		}
+	}
+
+	require.False(t, hasUnknownMarkets(newUpdate(mapping.MarketUpdateType, "10"), ev, nil))
+	require.False(t, hasUnknownMarkets(newUpdate(mapping.PriceUpdateType, "100"), ev, nil))
+	require.False(t, hasUnknownMarkets(newUpdate(mapping.EventUpdateType, "1"), ev, nil))
+	require.True(t, hasUnknownMarkets(newUpdate(mapping.MarketUpdateType, "11"), ev, nil))
+	require.True(t, hasUnknownMarkets(newUpdate(mapping.SelectionUpdateType, "110"), ev, nil))
+	require.True(t, hasUnknownMarkets(newUpdate(mapping.PriceUpdateType, "110"), ev, nil))
+
+	// the markets of the unhandled types are never stored
+	unhandled := map[string]struct{}{"11": {}, "110": {}}
+	require.False(t, hasUnknownMarkets(newUpdate(mapping.MarketUpdateType, "11"), ev, unhandled))
+	require.False(t, hasUnknownMarkets(newUpdate(mapping.SelectionUpdateType, "110"), ev, unhandled))
+	require.False(t, hasUnknownMarkets(newUpdate(mapping.PriceUpdateType, "110"), ev, unhandled))
+	require.True(t, hasUnknownMarkets(newUpdate(mapping.PriceUpdateType, "120"), ev, unhandled))
+}
+
+func TestRefreshCooldowns(t *testing.T) {
+	var (
+		r   = newRefreshCooldowns(time.Minute)
+		now = time.Now()
+	)
+	require.True(t, r.allow("1", now))
+	require.False(t, r.allow("1", now.Add(30*time.Second)))
+	require.True(t, r.allow("2", now.Add(30*time.Second)))
+	require.True(t, r.allow("1", now.Add(time.Minute)))
+	// the expired cooldowns are dropped
+	require.True(t, r.allow("3", now.Add(2*time.Minute)))
+	require.Len(t, r.last, 1)
+}
+
+func TestUnhandledMarkets(t *testing.T) {
+	u := newUnhandledMarkets()
+	require.Nil(t, u.get("1"))
+
+	u.set("1", map[string]struct{}{"10": {}})
+	require.Equal(t, map[string]struct{}{"10": {}}, u.get("1"))
+
+	u.forget("1")
+	require.Nil(t, u.get("1"))
+}
+
+func TestApplyEventUpdateRequestsRefresh(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		hash = "LIVE_EVENTS_BASKETBALL"
+		sent atomic.Int32
+		p    = newTestRedisPoller(t, doerFunc(func(request *sdkHttp.Request) (*sdkHttp.Response, error) {
+			sent.Add(1)
+			return &sdkHttp.Response{Status: 500}, nil
+		}))
+		refreshes = make(chan string, 1)
+		update    = &transform.Update{
+			Data: map[mapping.UpdateType][]*transform.UpdateData{
+				mapping.PriceUpdateType: {{ID: "110", RawData: []byte("{}")}},
+			},
+		}
+	)
+	p.config.Refresh.Cooldown = time.Minute
+	p.refreshes = newRefreshCooldowns(time.Minute)
+	require.NoError(t, p.storage.StoreEvent(ctx, hash, &pb.Event{
+		ExternalId: "1",
+		Markets: []*pb.Market{
+			{ExternalId: "10", Outcomes: []*pb.Outcome{{ExternalId: "100"}}},
+		},
+	}))
+
+	// the refresh is requested, not fetched while applying the update
+	require.NoError(t, p.applyEventUpdate(ctx, logrus.NewEntry(logrus.New()), pb.SportType_BASKETBALL, hash, "1", update, refreshes))
+	require.Equal(t, "1", <-refreshes)
+	require.Zero(t, sent.Load())
+
+	// cooling down
+	require.NoError(t, p.applyEventUpdate(ctx, logrus.NewEntry(logrus.New()), pb.SportType_BASKETBALL, hash, "1", update, refreshes))
+	require.Empty(t, refreshes)
+}
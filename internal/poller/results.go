@@ -0,0 +1,114 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/mapping"
+	"github.com/olafszymanski/int-ladbrokes/internal/model"
+	"github.com/olafszymanski/int-ladbrokes/internal/transform"
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	sdkHttp "github.com/olafszymanski/int-sdk/http"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/sirupsen/logrus"
+)
+
+const resultsUrl = "https://ss-aka-ori.ladbrokes.com/openbet-ssviewer/HistoricDrilldown/2.81/ResultedEvent/%s?translationLang=en&responseFormat=json"
+
+// pollResults queries the results of the ended live events, as the updates stop once the events are removed,
+// the outcomes resulted afterwards would be missed otherwise. The events are queried until they are fully resulted
+// or the max wait passes since they ended
+func (p *Poller) pollResults(ctx context.Context, logger *logrus.Entry, sportType pb.SportType) error {
+	key := fmt.Sprintf(config.PendingResultsStorageKey, sportType)
+	logger = logger.WithField("polling", "results")
+
+	return schedule(ctx, logger, p.config.Results.RequestInterval, func(ctx context.Context) error {
+		return p.resolvePendingResults(ctx, logger, key)
+	})
+}
+
+// resolvePendingResults queries the results of the pending events once, the events are no longer pending once
+// they are fully resulted or the max wait has passed, even if querying their results keeps failing
+func (p *Poller) resolvePendingResults(ctx context.Context, logger *logrus.Entry, key string) error {
+	pending, err := p.storage.GetPendingResults(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to get pending results: %s", err)
+	}
+
+	done := make([]string, 0)
+	for id, endedAt := range pending {
+		res, err := p.fetchResults(id)
+		if err != nil {
+			logger.WithError(err).WithField("event_external_id", id).Warn("failed to fetch results")
+		}
+		if res != nil && len(res.Outcomes) > 0 {
+			if err := p.recordResults(ctx, id, res.Outcomes); err != nil {
+				return fmt.Errorf("failed to store results: %s", err)
+			}
+		}
+		if res != nil && res.Resulted {
+			done = append(done, id)
+			continue
+		}
+		if time.Since(endedAt) > p.config.Results.MaxWait {
+			logger.WithField("event_external_id", id).Warn("event not resulted in time")
+			done = append(done, id)
+		}
+		// the rest of the events would fail the same way, they are queried on the next run
+		if err != nil && isUpstreamUnavailable(err) {
+			break
+		}
+	}
+	if len(done) > 0 {
+		if err := p.storage.DeletePendingResults(ctx, key, done); err != nil {
+			return fmt.Errorf("failed to delete pending results: %s", err)
+		}
+	}
+	return nil
+}
+
+// fetchResults fetches the results of the event, it returns nil if the event hasn't been resulted at all yet
+func (p *Poller) fetchResults(id string) (*transform.EventResults, error) {
+	// the results fallback is not time critical, it yields to the live polling
+	res, err := p.lowPriorityClient.Do(&sdkHttp.Request{
+		Method:  http.MethodGet,
+		URL:     fmt.Sprintf(resultsUrl, id),
+		Timeout: p.config.Results.RequestTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status != 200 {
+		return nil, fmt.Errorf("%w: %v", ErrUnexpectedStatusCode, res.Status)
+	}
+	rs, err := transform.TransformResults(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	return rs[id], nil
+}
+
+// recordResults merges the results of the outcomes into the stored results of the event, the later ones replace
+// the earlier results of the same outcomes
+func (p *Poller) recordResults(ctx context.Context, id string, outcomes []*ladbrokesPb.OutcomeResult) error {
+	_, err := p.storage.RecordResults(ctx, fmt.Sprintf(config.ResultsStorageKey, id), id, outcomes, p.config.Results.Retention)
+	return err
+}
+
+// getUpdateResults returns the results of the outcomes carried by the selection updates
+func getUpdateResults(update *transform.Update) ([]*ladbrokesPb.OutcomeResult, error) {
+	ors := make([]*ladbrokesPb.OutcomeResult, 0)
+	for _, data := range update.Data[mapping.SelectionUpdateType] {
+		u, err := transform.UnmarshalUpdate[model.SelectionUpdate](data.RawData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal update: %s", err)
+		}
+		if r := transform.TransformSelectionResult(data.ID, u); r != nil {
+			ors = append(ors, r)
+		}
+	}
+	return ors, nil
+}
@@ -0,0 +1,56 @@
+package poller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdkHttp "github.com/olafszymanski/int-sdk/http"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvePendingResultsFailing(t *testing.T) {
+	const key = "PENDING_RESULTS_BASKETBALL"
+
+	tests := []struct {
+		name    string
+		maxWait time.Duration
+		// ids of the events still pending
+		pending []string
+	}{
+		{
+			name:    "within max wait",
+			maxWait: time.Hour,
+			pending: []string{"1"},
+		},
+		{
+			name:    "max wait passed",
+			maxWait: 0,
+			pending: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the results of the void events are never found
+			p := newTestPoller(doerFunc(func(request *sdkHttp.Request) (*sdkHttp.Response, error) {
+				return &sdkHttp.Response{Status: 404}, nil
+			}))
+			p.lowPriorityClient = p.httpClient
+			p.config.Results.MaxWait = test.maxWait
+
+			ctx := context.Background()
+			require.NoError(t, p.storage.AddPendingResults(ctx, key, []string{"1"}))
+			require.NoError(t, p.resolvePendingResults(ctx, logrus.NewEntry(logrus.New()), key))
+
+			pending, err := p.storage.GetPendingResults(ctx, key)
+			require.NoError(t, err)
+			ids := make([]string, 0, len(pending))
+			for id := range pending {
+				ids = append(ids, id)
+			}
+			require.Equal(t, test.pending, ids)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/egress"
+	"github.com/olafszymanski/int-ladbrokes/internal/limiter"
+	"github.com/sirupsen/logrus"
+)
+
+// schedule runs the task every interval until the context is done or the task fails, the task runs in the caller's
+// goroutine, so the runs never overlap and nothing outlives the schedule. A run taking longer than the interval
+// is followed by the next one right away, a run blocked by the upstream or throttled by the upstream budget is skipped,
+// as it's retried on the next interval
+func schedule(ctx context.Context, logger *logrus.Entry, interval time.Duration, task func(ctx context.Context) error) error {
+	for {
+		st := time.Now()
+		if err := task(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !isUpstreamUnavailable(err) {
+				return err
+			}
+			logger.WithError(err).Warn("polling skipped, upstream unavailable")
+		}
+
+		el := time.Since(st)
+		if el > interval {
+			logger.WithField("duration", el).Warn("polling took longer than expected")
+		}
+		if err := wait(ctx, interval-el); err != nil {
+			return err
+		}
+	}
+}
+
+// isUpstreamUnavailable checks whether the request wasn't sent, as the upstream is blocking the requests
+// or their budget is exhausted, the following requests are likely to fail the same way until it recovers
+func isUpstreamUnavailable(err error) bool {
+	return errors.Is(err, egress.ErrBlocked) || errors.Is(err, limiter.ErrThrottled)
+}
+
+// wait blocks for the duration or until the context is done
+func wait(ctx context.Context, duration time.Duration) error {
+	if duration <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(duration)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
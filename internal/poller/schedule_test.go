@@ -0,0 +1,275 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/egress"
+	"github.com/olafszymanski/int-ladbrokes/internal/limiter"
+	"github.com/olafszymanski/int-ladbrokes/internal/shard"
+	"github.com/olafszymanski/int-ladbrokes/internal/storage"
+	sdkHttp "github.com/olafszymanski/int-sdk/http"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	sdkStorage "github.com/olafszymanski/int-sdk/storage"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type doerFunc func(request *sdkHttp.Request) (*sdkHttp.Response, error)
+
+func (f doerFunc) Do(request *sdkHttp.Request) (*sdkHttp.Response, error) {
+	return f(request)
+}
+
+func TestSchedule(t *testing.T) {
+	errTask := errors.New("task failed")
+
+	tc := []struct {
+		name string
+		// the task fails on the given run, it never fails if 0
+		failOn int32
+		// the context is canceled after the given run
+		cancelOn int32
+		// the runs before the given one are blocked by the upstream
+		blockedUntil int32
+		// the runs before the given one are throttled by the upstream budget
+		throttledUntil int32
+		err            error
+	}{
+		{
+			name:     "context done",
+			cancelOn: 3,
+			err:      context.Canceled,
+		},
+		{
+			name:   "task failed",
+			failOn: 3,
+			err:    errTask,
+		},
+		{
+			name:         "task blocked",
+			blockedUntil: 3,
+			cancelOn:     3,
+			err:          context.Canceled,
+		},
+		{
+			name:           "task throttled",
+			throttledUntil: 3,
+			cancelOn:       3,
+			err:            context.Canceled,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			defer goleak.VerifyNone(t)
+
+			var (
+				runs    atomic.Int32
+				running atomic.Int32
+			)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			err := schedule(ctx, logrus.NewEntry(logrus.New()), time.Millisecond, func(ctx context.Context) error {
+				require.Equal(t, int32(1), running.Add(1), "runs must not overlap")
+				defer running.Add(-1)
+
+				// every run takes longer than the interval
+				time.Sleep(2 * time.Millisecond)
+
+				r := runs.Add(1)
+				if r < c.blockedUntil {
+					return fmt.Errorf("polling failed: %w", &egress.BlockedError{Host: "a.com", Kind: egress.KindBan})
+				}
+				if r < c.throttledUntil {
+					return fmt.Errorf("polling failed: %w: a.com Events", limiter.ErrThrottled)
+				}
+				if r == c.failOn {
+					return errTask
+				}
+				if r == c.cancelOn {
+					cancel()
+				}
+				return nil
+			})
+			require.ErrorIs(t, err, c.err)
+			require.Equal(t, int32(3), runs.Load())
+		})
+	}
+}
+
+func TestFetchEventsFailure(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	var sent atomic.Int32
+	p := newTestPoller(doerFunc(func(request *sdkHttp.Request) (*sdkHttp.Response, error) {
+		// the first request fails, while the rest of them are still in flight
+		if sent.Add(1) == 1 {
+			return &sdkHttp.Response{Status: 500}, nil
+		}
+		time.Sleep(10 * time.Millisecond)
+		return &sdkHttp.Response{Status: 200, Body: []byte(`{"SSResponse":{"children":[]}}`)}, nil
+	}))
+
+	_, _, err := p.fetchEvents(context.Background(), eventsUrl, []byte("1,2"), time.Second, []timePeriod{
+		{start: -4 * time.Hour, end: 0},
+		{start: 0, end: 8 * time.Hour},
+		{start: 8 * time.Hour, open: true},
+	}, time.Now())
+	require.ErrorIs(t, err, ErrUnexpectedStatusCode)
+}
+
+func TestPollClassesCanceled(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	var polled atomic.Int32
+	p := newTestPoller(doerFunc(func(request *sdkHttp.Request) (*sdkHttp.Response, error) {
+		polled.Add(1)
+		return &sdkHttp.Response{Status: 200, Body: []byte(`{"SSResponse":{"children":[{"class":{"id":"1","isActive":"true"}}]}}`)}, nil
+	}))
+	p.config.Classes.RequestInterval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.pollClasses(ctx, logrus.NewEntry(logrus.New()), pb.SportType_BASKETBALL)
+	}()
+
+	require.Eventually(t, func() bool {
+		return polled.Load() >= 2
+	}, time.Second, time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-errCh, context.Canceled)
+
+	cls, err := p.storage.GetClasses(context.Background(), "CLASSES_BASKETBALL")
+	require.NoError(t, err)
+	require.Equal(t, []byte("1"), cls)
+}
+
+func TestPollLiveEventsCanceled(t *testing.T) {
+	// registered first, so that it runs once the storage has been closed
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	var polled atomic.Int32
+	p := newTestRedisPoller(t, doerFunc(func(request *sdkHttp.Request) (*sdkHttp.Response, error) {
+		polled.Add(1)
+		return &sdkHttp.Response{Status: 200, Body: getEventsResponse(t)}, nil
+	}))
+	p.membership = newTestMembership(t)
+	p.config.Live.RequestInterval = time.Millisecond
+	require.NoError(t, p.storage.StoreClasses(context.Background(), fmt.Sprintf(classesStorageKey, pb.SportType_BASKETBALL), []byte("1")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.pollLiveEvents(ctx, logrus.NewEntry(logrus.New()), pb.SportType_BASKETBALL, make(chan *liveSetChange, 1))
+	}()
+
+	require.Eventually(t, func() bool {
+		return polled.Load() >= 2
+	}, time.Second, time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-errCh, context.Canceled)
+}
+
+func TestPollPreMatchEventsCanceled(t *testing.T) {
+	// registered first, so that it runs once the storage has been closed
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	var polled atomic.Int32
+	p := newTestRedisPoller(t, doerFunc(func(request *sdkHttp.Request) (*sdkHttp.Response, error) {
+		polled.Add(1)
+		return &sdkHttp.Response{Status: 200, Body: getEventsResponse(t)}, nil
+	}))
+	p.membership = newTestMembership(t)
+	p.config.PreMatch.TimePeriods = []config.TimePeriod{
+		{Start: 0, End: time.Hour, RequestInterval: time.Millisecond, RequestTimeout: time.Second},
+		{Start: time.Hour, Open: true, RequestInterval: time.Millisecond, RequestTimeout: time.Second},
+	}
+	require.NoError(t, p.storage.StoreClasses(context.Background(), fmt.Sprintf(classesStorageKey, pb.SportType_BASKETBALL), []byte("1")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.pollPreMatchEvents(ctx, logrus.NewEntry(logrus.New()), pb.SportType_BASKETBALL)
+	}()
+
+	// both of the time periods are polled
+	require.Eventually(t, func() bool {
+		return polled.Load() >= 4
+	}, time.Second, time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-errCh, context.Canceled)
+}
+
+func TestWatchStartingEventsCanceled(t *testing.T) {
+	// registered first, so that it runs once the storage has been closed
+	t.Cleanup(func() {
+		goleak.VerifyNone(t)
+	})
+
+	var polled atomic.Int32
+	p := newTestRedisPoller(t, doerFunc(func(request *sdkHttp.Request) (*sdkHttp.Response, error) {
+		polled.Add(1)
+		// no update received
+		return &sdkHttp.Response{Status: 200}, nil
+	}))
+	p.membership = newTestMembership(t)
+	p.config.Live.RequestInterval = time.Millisecond
+	p.config.Updates.MaxLongPolls = 1
+	p.config.Lifecycle.StartWatch = 5 * time.Minute
+	p.config.Lifecycle.WatchInterval = time.Millisecond
+	require.NoError(t, p.storage.StoreEvents(context.Background(), fmt.Sprintf(config.PreMatchEventsStorageKey, pb.SportType_BASKETBALL), []*pb.Event{
+		{ExternalId: "1", StartTime: timestamppb.New(time.Now().Add(time.Minute))},
+		{ExternalId: "2", StartTime: timestamppb.New(time.Now().Add(time.Minute))},
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.watchStartingEvents(ctx, logrus.NewEntry(logrus.New()), pb.SportType_BASKETBALL)
+	}()
+
+	// the long polls of both of the events are in flight or waiting for the slot
+	require.Eventually(t, func() bool {
+		return polled.Load() >= 4
+	}, time.Second, time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-errCh, context.Canceled)
+}
+
+func newTestPoller(httpClient sdkHttp.Doer) *Poller {
+	cfg := &config.Config{}
+	cfg.Events.MaxUrlLength = 2048
+	cfg.Events.MaxResponseSize = 1 << 20
+	cfg.Events.MinTimeWindow = 15 * time.Minute
+	cfg.Events.OpenTimeWindowSplit = 24 * time.Hour
+
+	p, _ := NewPoller(cfg, httpClient, storage.NewStorage(sdkStorage.NewMemoryStorage(), nil, nil, 0), nil, nil)
+	return p
+}
+
+// newTestMembership returns the membership of the single test instance, which owns all of the classes
+func newTestMembership(t *testing.T) *shard.Membership {
+	t.Helper()
+
+	rc := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() {
+		rc.Close()
+	})
+	return shard.NewMembership(rc, "test", time.Minute)
+}
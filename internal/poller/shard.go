@@ -0,0 +1,46 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/shard"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+)
+
+// getShard returns the shard the classes are distributed by among the pollers of the sport,
+// it is computed on every call, so the classes are rebalanced as soon as the pollers join or leave
+func (p *Poller) getShard(ctx context.Context, sportType pb.SportType) (*shard.Shard, error) {
+	return p.membership.GetShard(ctx, fmt.Sprintf(config.PollerMembersStorageKey, sportType))
+}
+
+// getOwnedClasses returns the stored classes owned by the instance
+func getOwnedClasses(sh *shard.Shard, classes []byte) []byte {
+	owned := make([]string, 0)
+	for _, c := range strings.Split(string(classes), ",") {
+		if c != "" && sh.Owns(c) {
+			owned = append(owned, c)
+		}
+	}
+	return []byte(strings.Join(owned, ","))
+}
+
+// getEventsOwner returns the function telling whether the event with the given id is owned by the instance,
+// events stored without their class are owned by a single instance as well
+func getEventsOwner(sh *shard.Shard, eventsClasses map[string]string) func(id string) bool {
+	return func(id string) bool {
+		return sh.Owns(eventsClasses[id])
+	}
+}
+
+func filterOwnedEvents(events []*pb.Event, owns func(id string) bool) []*pb.Event {
+	evs := make([]*pb.Event, 0, len(events))
+	for _, e := range events {
+		if owns(e.ExternalId) {
+			evs = append(evs, e)
+		}
+	}
+	return evs
+}
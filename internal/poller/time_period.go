@@ -1,13 +1,67 @@
 package poller
 
-import "time"
+import (
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+)
 
 type timePeriod struct {
 	start time.Duration
 	end   time.Duration
+	// the period does not have an end time
+	open bool
+}
+
+func newTimePeriod(period config.TimePeriod) timePeriod {
+	return timePeriod{
+		start: period.Start,
+		end:   period.End,
+		open:  period.Open,
+	}
 }
 
-func (p *timePeriod) getTimes() (time.Time, time.Time) {
-	n := time.Now().UTC()
+// getTimes returns the start and the end of the period relative to now
+func (p *timePeriod) getTimes(now time.Time) (time.Time, time.Time) {
+	n := now.UTC()
 	return n.Add(p.start), n.Add(p.end)
 }
+
+// contains checks whether the time is within the period relative to now, the lower bound is ignored if unbounded is set
+func (p *timePeriod) contains(t, now time.Time, unbounded bool) bool {
+	st, et := p.getTimes(now)
+	if !unbounded && t.Before(st) {
+		return false
+	}
+	return p.open || t.Before(et)
+}
+
+// canSplit checks whether the period can be split without its parts getting shorter than the min duration
+func (p *timePeriod) canSplit(min time.Duration) bool {
+	return p.open || (p.end-p.start)/2 >= min
+}
+
+// split divides the period in halves, the open one is split at the given offset from its start
+func (p *timePeriod) split(openSplit time.Duration) (timePeriod, timePeriod) {
+	mid := p.start + openSplit
+	if !p.open {
+		mid = p.start + ((p.end - p.start) / 2).Truncate(time.Minute)
+	}
+	return timePeriod{
+		start: p.start,
+		end:   mid,
+	}, timePeriod{
+		start: mid,
+		end:   p.end,
+		open:  p.open,
+	}
+}
+
+// mergeTimePeriods joins the adjacent periods into a single one
+func mergeTimePeriods(a, b timePeriod) timePeriod {
+	return timePeriod{
+		start: a.start,
+		end:   b.end,
+		open:  b.open,
+	}
+}
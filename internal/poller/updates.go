@@ -2,18 +2,23 @@ package poller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"sync"
 	"time"
 
 	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/delta"
 	"github.com/olafszymanski/int-ladbrokes/internal/mapping"
+	"github.com/olafszymanski/int-ladbrokes/internal/metrics"
 	"github.com/olafszymanski/int-ladbrokes/internal/model"
 	"github.com/olafszymanski/int-ladbrokes/internal/transform"
 	sdkHttp "github.com/olafszymanski/int-sdk/http"
 	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/olafszymanski/int-sdk/storage"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -22,112 +27,282 @@ const (
 	defaultRequestBody = "CL0000S0002sEVENT0%[1]sSEVENT0%[1]s!!!!!!!!!0"
 )
 
-type pollingInfo struct {
-	polling bool
-	body    []byte
-}
-
-func (p *Poller) pollUpdates(ctx context.Context, logger *logrus.Entry, sportType pb.SportType) error {
+// pollUpdates subscribes to the updates of the live events polled by the instance, the subscriptions are started
+// and stopped by the changes of the live events set only. The number of the long polls in flight is bounded,
+// the received updates are applied by the processor, while the events of the degraded subscriptions are suspended
+func (p *Poller) pollUpdates(ctx context.Context, logger *logrus.Entry, sportType pb.SportType, changes <-chan *liveSetChange) error {
 	logger.Debug("polling updates")
 
 	var (
-		lock     = sync.Mutex{}
-		hash     = fmt.Sprintf(config.LiveEventsStorageKey, sportType)
-		pollInfo = make(map[string]*pollingInfo)
-		idCh     = make(chan string)
-		errCh    = make(chan error)
+		hash      = fmt.Sprintf(config.LiveEventsStorageKey, sportType)
+		subs      = make(map[string]context.CancelFunc)
+		longPolls = make(chan struct{}, p.config.Updates.MaxLongPolls)
+		// the events to refresh, as their updates referenced unknown markets
+		refreshes = make(chan string, p.config.Updates.MaxQueueDepth)
+		processor = newUpdatesProcessor(
+			p.config.Updates.Workers,
+			p.config.Updates.MaxQueueDepth,
+			metrics.UpdatesQueueDepth.WithLabelValues(sportType.String()),
+			func(ctx context.Context, id string, update *transform.Update) error {
+				return p.applyUpdate(ctx, logger, sportType, hash, id, update, refreshes)
+			},
+		)
+		health = newFeedHealth(p.config.Updates.StaleAfter, p.config.Updates.MaxErrors)
 	)
-	defer close(errCh)
+	// every event is long polled in its own goroutine, the group makes sure none of them outlives the polling
+	g, ctx := errgroup.WithContext(ctx)
 
-	go func() {
+	g.Go(func() error {
+		return processor.run(ctx)
+	})
+	g.Go(func() error {
+		return p.refreshUnknownEvents(ctx, logger, sportType, hash, refreshes)
+	})
+	g.Go(func() error {
+		return p.monitorFeedHealth(ctx, logger, hash, health, metrics.EventsSuspensions.WithLabelValues(sportType.String()))
+	})
+	g.Go(func() error {
 		for {
-			ids, err := p.storage.GetEventsIds(ctx, hash)
-			if err != nil {
-				errCh <- fmt.Errorf("failed to get events ids for updates polling: %s", err)
-				return
-			}
-			if len(ids) == 0 {
-				continue
-			}
-			for _, id := range ids {
-				id := id
-
-				go func() {
-					lock.Lock()
-					defer lock.Unlock()
-					if info, ok := pollInfo[id]; !ok {
-						pollInfo[id] = &pollingInfo{
-							polling: true,
-							body:    nil,
-						}
-						idCh <- id
-					} else if !info.polling {
-						pollInfo[id].polling = true
-						idCh <- id
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case c := <-changes:
+				for _, id := range c.removed {
+					if cancel, ok := subs[id]; ok {
+						cancel()
+						delete(subs, id)
+						health.untrack(id)
+						p.unhandled.forget(id)
 					}
-				}()
+				}
+				for _, id := range c.added {
+					if _, ok := subs[id]; ok {
+						continue
+					}
+					sctx, cancel := context.WithCancel(ctx)
+					subs[id] = cancel
+					health.track(id)
+
+					id := id
+					g.Go(func() error {
+						return p.subscribeEventUpdates(sctx, logger, hash, id, longPolls, processor, health)
+					})
+				}
+				logger.WithField("subscriptions", len(subs)).Debug("updates subscriptions changed")
 			}
 		}
-	}()
+	})
+
+	return g.Wait()
+}
 
+// subscribeEventUpdates long polls the updates of the event until the context is done and queues them to the processor.
+// The subscription continues from the stored cursor if there is one, so that the updates are neither missed nor replayed
+// after a restart, a rejected cursor is replaced by a fresh subscription and the event is resynced from its snapshot
+func (p *Poller) subscribeEventUpdates(ctx context.Context, logger *logrus.Entry, hash, id string, longPolls chan struct{}, processor *updatesProcessor, health *feedHealth) error {
+	logger = logger.WithField("event_external_id", id)
+	body, resumed := p.getSubscriptionRequestBody(ctx, logger, id)
+	// the cursor is only valid for the egress it was issued to
+	cl := p.getSessionClient(id)
 	for {
+		health.queue(id)
 		select {
-		case id := <-idCh:
-			go func() {
-				lock.Lock()
-				body := pollInfo[id].body
-				if body == nil {
-					body = []byte(fmt.Sprintf(defaultRequestBody, id))
+		case <-ctx.Done():
+			return nil
+		case longPolls <- struct{}{}:
+		}
+		health.dequeue(id)
+		update, err := p.getUpdates(cl, body, time.Second*60)
+		<-longPolls
+
+		// the subscription has been stopped while polling, the event is not live anymore
+		if ctx.Err() != nil {
+			return nil
+		}
+		// only the first long poll of a resumed subscription can tell that the cursor is not valid anymore
+		if resumed && errors.Is(err, ErrUnexpectedStatusCode) {
+			logger.WithError(err).Warn("updates cursor rejected, resubscribing")
+			// the cursor is tried again on the next long poll, so that the resync is retried until it succeeds
+			if err := p.resubscribeEventUpdates(ctx, hash, id); err != nil {
+				health.fail(id)
+				logger.WithError(err).Warn("failed to resubscribe updates")
+				if err := wait(ctx, p.config.Live.RequestInterval); err != nil {
+					return nil
 				}
-				lock.Unlock()
+				continue
+			}
+			body, resumed = []byte(fmt.Sprintf(defaultRequestBody, id)), false
+			continue
+		}
+		// the failing long polls are retried, the event is suspended by the health monitor if they keep failing
+		if err != nil {
+			health.fail(id)
+			logger.WithError(err).Warn("failed to receive update")
+			if err := wait(ctx, p.config.Live.RequestInterval); err != nil {
+				return nil
+			}
+			continue
+		}
+		resumed = false
+		if health.succeed(id) {
+			if err := p.restoreEvent(ctx, hash, id, health); err != nil {
+				logger.WithError(err).Warn("failed to restore suspended event")
+			}
+		}
+		// no update received, the next long poll is delayed so that an immediately empty response doesn't spin the loop
+		if update == nil {
+			logger.Debug("no update")
+			if err := wait(ctx, p.config.Live.RequestInterval); err != nil {
+				return nil
+			}
+			continue
+		}
 
-				st := time.Now()
+		body = getRequestBody(id, update.RequestBodyParts)
+		// blocks while the processor is saturated, holding back the next long poll
+		if err := processor.enqueue(ctx, id, update); err != nil {
+			return nil
+		}
+	}
+}
 
-				update, err := p.getUpdates(body, time.Second*60)
-				if err != nil {
-					errCh <- fmt.Errorf("failed to receive update: %s", err)
-					return
-				}
-				// no update received, we don't have to do anything
-				if update == nil {
-					logger.WithField("event_external_id", id).Debug("no update")
-					return
-				}
+// getSubscriptionRequestBody returns the request body continuing from the stored cursor of the event and true,
+// or the one of a fresh subscription and false if the cursor is missing or not valid
+func (p *Poller) getSubscriptionRequestBody(ctx context.Context, logger *logrus.Entry, id string) ([]byte, bool) {
+	parts, err := p.storage.GetUpdatesCursor(ctx, id)
+	// without the cursor the subscription starts over, failing to get it must not stop the subscription
+	if err != nil {
+		logger.WithError(err).Warn("failed to get updates cursor, subscribing from scratch")
+		return []byte(fmt.Sprintf(defaultRequestBody, id)), false
+	}
+	if parts == nil {
+		return []byte(fmt.Sprintf(defaultRequestBody, id)), false
+	}
+	if !transform.IsValidRequestBodyParts(parts) {
+		logger.WithField("cursor", parts).Warn("invalid updates cursor, subscribing from scratch")
+		return []byte(fmt.Sprintf(defaultRequestBody, id)), false
+	}
+	logger.Debug("resuming updates subscription")
+	return getRequestBody(id, parts), true
+}
 
-				ev, err := p.storage.GetEvent(ctx, hash, id)
-				if err != nil {
-					errCh <- fmt.Errorf("failed to get event from storage: %s", err)
-					return
-				}
-				if err := updateEvent(update, ev); err != nil {
-					errCh <- fmt.Errorf("failed to update event: %s", err)
-					return
-				}
-				if err := p.storage.StoreEvent(ctx, hash, ev); err != nil {
-					errCh <- fmt.Errorf("failed to save event: %s", err)
-					return
-				}
+// resubscribeEventUpdates invalidates the rejected cursor of the event and resyncs the stored event from its snapshot,
+// as the updates published since the cursor was stored won't be received by the fresh subscription
+func (p *Poller) resubscribeEventUpdates(ctx context.Context, hash, id string) error {
+	if err := p.storage.StoreUpdatesCursor(ctx, id, nil, p.config.Updates.CursorTTL); err != nil {
+		return fmt.Errorf("failed to invalidate updates cursor: %s", err)
+	}
 
-				lock.Lock()
-				pollInfo[id].polling = false
-				pollInfo[id].body = getRequestBody(id, update.RequestBodyParts)
-				lock.Unlock()
-
-				logger.WithFields(logrus.Fields{
-					"event_external_id": id,
-					"start_time":        st,
-					"duration":          time.Since(st),
-				}).Debug("update received")
-			}()
-			continue
-		case err := <-errCh:
-			return err
+	unlock := p.locks.acquire(id)
+	defer unlock()
+	return p.resyncEvent(ctx, hash, id)
+}
+
+// resyncEvent replaces the stored event with its snapshot, the caller must hold the lock of the event
+func (p *Poller) resyncEvent(ctx context.Context, hash, id string) error {
+	ev, err := p.fetchEvent(id, p.config.Live.RequestTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to fetch event snapshot: %s", err)
+	}
+	// the event is gone, the live events polling is about to remove it
+	if ev == nil {
+		return nil
+	}
+	curr, err := p.storage.GetEvent(ctx, hash, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
 		}
+		return fmt.Errorf("failed to get event from storage: %s", err)
+	}
+	if err := p.storage.StoreEvent(ctx, hash, ev); err != nil {
+		return fmt.Errorf("failed to save event: %s", err)
 	}
+	if err := p.storage.PublishDeltas(ctx, fmt.Sprintf(config.EventsDeltasChannelKey, hash), delta.GetEventDeltas(curr, ev)); err != nil {
+		return fmt.Errorf("failed to publish event deltas: %s", err)
+	}
+	return nil
 }
 
-func (p *Poller) getUpdates(requestBody []byte, timeout time.Duration) (*transform.Update, error) {
-	res, err := p.httpClient.Do(&sdkHttp.Request{
+// applyUpdate applies the update of the event and stores its cursor, the cursor is stored only once the update
+// is applied, so that a restart resumes the subscription right after the last applied update instead of skipping it
+func (p *Poller) applyUpdate(ctx context.Context, logger *logrus.Entry, sportType pb.SportType, hash, id string, update *transform.Update, refreshes chan<- string) error {
+	if err := p.applyEventUpdate(ctx, logger, sportType, hash, id, update, refreshes); err != nil {
+		return err
+	}
+	if err := p.storage.StoreUpdatesCursor(ctx, id, update.RequestBodyParts, p.config.Updates.CursorTTL); err != nil {
+		logger.WithError(err).WithField("event_external_id", id).Warn("failed to store updates cursor")
+	}
+	return nil
+}
+
+// applyEventUpdate applies the update to the stored event and publishes the resulting deltas, the event is requested
+// to be refreshed if the update referenced any of its unknown markets
+func (p *Poller) applyEventUpdate(ctx context.Context, logger *logrus.Entry, sportType pb.SportType, hash, id string, update *transform.Update, refreshes chan<- string) error {
+	st := time.Now()
+
+	unlock := p.locks.acquire(id)
+	defer unlock()
+
+	started, err := isEventStarted(update)
+	if err != nil {
+		return fmt.Errorf("failed to check event status: %s", err)
+	}
+	// the results are recorded even if the event has been removed already
+	ors, err := getUpdateResults(update)
+	if err != nil {
+		return fmt.Errorf("failed to get results: %s", err)
+	}
+	if len(ors) > 0 {
+		if err := p.recordResults(ctx, id, ors); err != nil {
+			return fmt.Errorf("failed to record results: %s", err)
+		}
+	}
+
+	ev, err := p.storage.GetEvent(ctx, hash, id)
+	if err != nil {
+		// the event has been removed in the meantime, its subscription is about to be stopped
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get event from storage: %s", err)
+	}
+	curr, _ := proto.Clone(ev).(*pb.Event)
+	if err := updateEvent(update, ev); err != nil {
+		return fmt.Errorf("failed to update event: %s", err)
+	}
+	if err := p.storage.StoreEvent(ctx, hash, ev); err != nil {
+		return fmt.Errorf("failed to save event: %s", err)
+	}
+	if err := p.storage.PublishDeltas(ctx, fmt.Sprintf(config.EventsDeltasChannelKey, hash), delta.GetEventDeltas(curr, ev)); err != nil {
+		return fmt.Errorf("failed to publish event deltas: %s", err)
+	}
+	// the updates of the markets opened after the event was stored are lost until the markets are fetched,
+	// the refresh is dropped if too many of them are requested already, it's requested again after the cooldown
+	if hasUnknownMarkets(update, ev, p.unhandled.get(id)) && p.refreshes.allow(id, time.Now()) {
+		select {
+		case refreshes <- id:
+		default:
+			logger.WithField("event_external_id", id).Warn("too many events refreshes requested, dropping refresh")
+		}
+	}
+	// the event might still be among the pre-match ones, if the push came before any of the pollings noticed the start
+	if started {
+		if err := p.startEvents(ctx, sportType, []*pb.Event{ev}); err != nil {
+			return fmt.Errorf("failed to start event: %s", err)
+		}
+	}
+
+	logger.WithFields(logrus.Fields{
+		"event_external_id": id,
+		"start_time":        st,
+		"duration":          time.Since(st),
+	}).Debug("update applied")
+	return nil
+}
+
+func (p *Poller) getUpdates(httpClient sdkHttp.Doer, requestBody []byte, timeout time.Duration) (*transform.Update, error) {
+	res, err := httpClient.Do(&sdkHttp.Request{
 		Method:  http.MethodPost,
 		URL:     updatesUrl,
 		Body:    requestBody,
@@ -146,11 +321,6 @@ func updateEvent(update *transform.Update, event *pb.Event) error {
 	for t, update := range update.Data {
 		for _, data := range update {
 			switch t {
-			// case mapping.EventUpdateType:
-			// 	u, err := transform.UnmarshalUpdate[model.EventUpdate](data.RawData)
-			// 	if err != nil {
-			// 		return fmt.Errorf("failed to unmarshal update: %s", err)
-			// 	}
 			case mapping.PriceUpdateType:
 				u, err := transform.UnmarshalUpdate[model.PriceUpdate](data.RawData)
 				if err != nil {
@@ -170,6 +340,20 @@ func updateEvent(update *transform.Update, event *pb.Event) error {
 	return nil
 }
 
+// isEventStarted checks whether any of the event updates signals that the event has started
+func isEventStarted(update *transform.Update) (bool, error) {
+	for _, data := range update.Data[mapping.EventUpdateType] {
+		u, err := transform.UnmarshalUpdate[model.EventUpdate](data.RawData)
+		if err != nil {
+			return false, fmt.Errorf("failed to unmarshal update: %s", err)
+		}
+		if u.Started == model.YesUpdateFlag || u.IsOff == model.YesUpdateFlag {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func getRequestBody(id string, requestBodyParts []string) []byte {
 	return []byte(fmt.Sprintf("CL0000S0001sEVENT0%[1]s!!!!%[2]sS0001SEVENT0%[1]s!!!!%[3]s", id, requestBodyParts[0], requestBodyParts[1]))
 }
@@ -0,0 +1,248 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/mapping"
+	"github.com/olafszymanski/int-ladbrokes/internal/transform"
+	sdkHttp "github.com/olafszymanski/int-sdk/http"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestPollUpdatesSubscriptions(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	var (
+		lock  = sync.Mutex{}
+		polls = make(map[string]int)
+	)
+	getPolls := func(id string) int {
+		lock.Lock()
+		defer lock.Unlock()
+		return polls[id]
+	}
+	p := newTestPoller(doerFunc(func(request *sdkHttp.Request) (*sdkHttp.Response, error) {
+		lock.Lock()
+		for _, id := range []string{"1", "2"} {
+			if strings.Contains(string(request.Body), "EVENT0"+id) {
+				polls[id]++
+			}
+		}
+		lock.Unlock()
+		// no update received
+		return &sdkHttp.Response{Status: 200}, nil
+	}))
+	p.config.Live.RequestInterval = time.Millisecond
+	p.config.Updates.Workers = 2
+	p.config.Updates.MaxQueueDepth = 16
+	p.config.Updates.MaxLongPolls = 4
+	p.config.Updates.StaleAfter = time.Minute
+	p.config.Updates.MaxErrors = 3
+	p.config.Updates.HealthCheckInterval = time.Millisecond
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		changes     = make(chan *liveSetChange)
+		errCh       = make(chan error, 1)
+	)
+	go func() {
+		errCh <- p.pollUpdates(ctx, logrus.NewEntry(logrus.New()), pb.SportType_BASKETBALL, changes)
+	}()
+
+	changes <- &liveSetChange{added: []string{"1", "2"}}
+	require.Eventually(t, func() bool {
+		return getPolls("1") >= 2 && getPolls("2") >= 2
+	}, time.Second, time.Millisecond)
+
+	changes <- &liveSetChange{removed: []string{"1"}}
+	// the subscription might be in the middle of a long poll while being stopped
+	time.Sleep(10 * time.Millisecond)
+	removed, kept := getPolls("1"), getPolls("2")
+	require.Eventually(t, func() bool {
+		return getPolls("2") >= kept+2
+	}, time.Second, time.Millisecond)
+	require.Equal(t, removed, getPolls("1"))
+
+	cancel()
+	require.ErrorIs(t, <-errCh, context.Canceled)
+}
+
+func TestSubscribeEventUpdatesRejectedCursor(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	var (
+		lock     = sync.Mutex{}
+		requests = make([]string, 0)
+		parts    = []string{"'o0:FC", "!!!!!0"}
+	)
+	getRequests := func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]string{}, requests...)
+	}
+	p := newTestPoller(doerFunc(func(request *sdkHttp.Request) (*sdkHttp.Response, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if request.Body == nil {
+			requests = append(requests, request.URL)
+			return &sdkHttp.Response{Status: 200, Body: []byte(`{"SSResponse":{"children":[]}}`)}, nil
+		}
+		requests = append(requests, string(request.Body))
+		// the stored cursor has expired on the push server
+		if string(request.Body) == string(getRequestBody("1", parts)) {
+			return &sdkHttp.Response{Status: 400}, nil
+		}
+		return &sdkHttp.Response{Status: 200}, nil
+	}))
+	p.config.Live.RequestInterval = time.Millisecond
+	p.config.Updates.CursorTTL = time.Minute
+	require.NoError(t, p.storage.StoreUpdatesCursor(context.Background(), "1", parts, time.Minute))
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		errCh       = make(chan error, 1)
+		processor   = newUpdatesProcessor(1, 1, prometheus.NewGauge(prometheus.GaugeOpts{Name: "depth"}), func(ctx context.Context, id string, update *transform.Update) error {
+			return nil
+		})
+	)
+	go func() {
+		errCh <- p.subscribeEventUpdates(ctx, logrus.NewEntry(logrus.New()), "LIVE_EVENTS_BASKETBALL", "1", make(chan struct{}, 1), processor, newFeedHealth(time.Minute, 3))
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(getRequests()) >= 3
+	}, time.Second, time.Millisecond)
+	cancel()
+	require.NoError(t, <-errCh)
+
+	// the cursor is tried first, then the event is resynced from its snapshot and subscribed from scratch
+	reqs := getRequests()
+	require.Equal(t, string(getRequestBody("1", parts)), reqs[0])
+	require.Equal(t, fmt.Sprintf(eventUrl, "1"), reqs[1])
+	require.Equal(t, fmt.Sprintf(defaultRequestBody, "1"), reqs[2])
+
+	stored, err := p.storage.GetUpdatesCursor(context.Background(), "1")
+	require.NoError(t, err)
+	require.Nil(t, stored)
+}
+
+func TestSubscribeEventUpdatesResyncFailed(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	var (
+		lock      = sync.Mutex{}
+		requests  = make([]string, 0)
+		snapshots = 0
+		parts     = []string{"'o0:FC", "!!!!!0"}
+	)
+	getRequests := func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]string{}, requests...)
+	}
+	p := newTestPoller(doerFunc(func(request *sdkHttp.Request) (*sdkHttp.Response, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if request.Body == nil {
+			requests = append(requests, request.URL)
+			// the first snapshot fetch fails
+			if snapshots++; snapshots == 1 {
+				return &sdkHttp.Response{Status: 500}, nil
+			}
+			return &sdkHttp.Response{Status: 200, Body: []byte(`{"SSResponse":{"children":[]}}`)}, nil
+		}
+		requests = append(requests, string(request.Body))
+		if string(request.Body) == string(getRequestBody("1", parts)) {
+			return &sdkHttp.Response{Status: 400}, nil
+		}
+		return &sdkHttp.Response{Status: 200}, nil
+	}))
+	p.config.Live.RequestInterval = time.Millisecond
+	p.config.Updates.CursorTTL = time.Minute
+	require.NoError(t, p.storage.StoreUpdatesCursor(context.Background(), "1", parts, time.Minute))
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		errCh       = make(chan error, 1)
+		processor   = newUpdatesProcessor(1, 1, prometheus.NewGauge(prometheus.GaugeOpts{Name: "depth"}), func(ctx context.Context, id string, update *transform.Update) error {
+			return nil
+		})
+	)
+	go func() {
+		errCh <- p.subscribeEventUpdates(ctx, logrus.NewEntry(logrus.New()), "LIVE_EVENTS_BASKETBALL", "1", make(chan struct{}, 1), processor, newFeedHealth(time.Minute, 3))
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(getRequests()) >= 5
+	}, time.Second, time.Millisecond)
+	cancel()
+	require.NoError(t, <-errCh)
+
+	// the failed resync doesn't stop the subscription, it's retried with the next long poll
+	reqs := getRequests()
+	require.Equal(t, []string{
+		string(getRequestBody("1", parts)),
+		fmt.Sprintf(eventUrl, "1"),
+		string(getRequestBody("1", parts)),
+		fmt.Sprintf(eventUrl, "1"),
+		fmt.Sprintf(defaultRequestBody, "1"),
+	}, reqs[:5])
+}
+
+func TestApplyUpdateCursor(t *testing.T) {
+	parts := []string{"'o0:FC", "!!!!!0"}
+
+	tests := []struct {
+		name   string
+		update *transform.Update
+		err    bool
+		cursor []string
+	}{
+		{
+			name: "applied",
+			update: &transform.Update{
+				Data:             map[mapping.UpdateType][]*transform.UpdateData{},
+				RequestBodyParts: parts,
+			},
+			cursor: parts,
+		},
+		{
+			name: "failed",
+			update: &transform.Update{
+				Data: map[mapping.UpdateType][]*transform.UpdateData{
+					mapping.EventUpdateType: {{ID: "1", RawData: []byte("{")}},
+				},
+				RequestBodyParts: parts,
+			},
+			err:    true,
+			cursor: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newTestPoller(nil)
+			p.config.Updates.CursorTTL = time.Minute
+			err := p.applyUpdate(context.Background(), logrus.NewEntry(logrus.New()), pb.SportType_BASKETBALL, "LIVE_EVENTS_BASKETBALL", "1", test.update, make(chan string, 1))
+			if test.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// the cursor of the update not applied is not stored, so that a restart doesn't skip it
+			cursor, err := p.storage.GetUpdatesCursor(context.Background(), "1")
+			require.NoError(t, err)
+			require.Equal(t, test.cursor, cursor)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"math"
+	"time"
+
+	"github.com/Danny-Dasilva/CycleTLS/cycletls"
+	"github.com/olafszymanski/int-sdk/http"
+)
+
+// Client sends the requests through the proxy, otherwise the same way the sdk client does
+type Client struct {
+	client *cycletls.CycleTLS
+	proxy  string
+}
+
+// NewClient returns the client of the proxy, the cycletls client is shared by the clients of every proxy
+func NewClient(client *cycletls.CycleTLS, proxy string) *Client {
+	return &Client{
+		client: client,
+		proxy:  proxy,
+	}
+}
+
+func (c *Client) Do(request *http.Request) (*http.Response, error) {
+	t := time.Now()
+	r, err := c.client.Do(request.URL, c.getOptions(request), request.Method)
+	res := &http.Response{
+		Status:    r.Status,
+		Body:      []byte(r.Body),
+		Headers:   r.Headers,
+		TimeTaken: time.Since(t),
+	}
+	return res, err
+}
+
+func (c *Client) getOptions(request *http.Request) cycletls.Options {
+	opts := cycletls.Options{
+		Proxy: c.proxy,
+	}
+	if request.Body != nil {
+		opts.Body = string(request.Body)
+	}
+	if request.Headers != nil {
+		opts.Headers = request.Headers
+	}
+	// cycletls takes the timeout in whole seconds
+	if request.Timeout != 0 {
+		opts.Timeout = int(math.Ceil(request.Timeout.Seconds()))
+	}
+	return opts
+}
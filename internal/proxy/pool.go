@@ -0,0 +1,225 @@
+package proxy
+
+import (
+	"errors"
+	"math/rand"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/egress"
+	"github.com/olafszymanski/int-ladbrokes/internal/metrics"
+	"github.com/olafszymanski/int-sdk/http"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	failuresEjection = "failures"
+	blockedEjection  = "blocked"
+)
+
+// Pool sends every request through one of the proxies, picked in proportion to their weights. The proxies failing
+// the requests repeatedly or blocked by the upstream are ejected for the ejection time, the requests of the same
+// session stick to the same proxy as long as it's not ejected
+type Pool struct {
+	proxies      []*proxy
+	maxFailures  int
+	ejectionTime time.Duration
+	sessionTTL   time.Duration
+	lock         sync.Mutex
+	sessions     map[string]*session
+	now          func() time.Time
+	random       func(n int) int
+}
+
+type proxy struct {
+	// the url without the credentials
+	name   string
+	weight int
+	doer   http.Doer
+	// consecutive failed requests
+	failures     int
+	ejected      bool
+	ejectedUntil time.Time
+}
+
+type session struct {
+	proxy    *proxy
+	lastUsed time.Time
+}
+
+// NewPool returns the pool of the configured proxies, newDoer returns the doer sending the requests through the proxy
+func NewPool(cfg *config.Config, newDoer func(proxyUrl string) http.Doer) *Pool {
+	p := &Pool{
+		proxies:      make([]*proxy, 0, len(cfg.Proxy.Proxies)),
+		maxFailures:  cfg.Proxy.MaxFailures,
+		ejectionTime: cfg.Proxy.EjectionTime,
+		sessionTTL:   cfg.Proxy.SessionTTL,
+		sessions:     make(map[string]*session),
+		now:          time.Now,
+		random:       rand.Intn,
+	}
+	for _, pr := range cfg.Proxy.Proxies {
+		n := getProxyName(pr.URL)
+		p.proxies = append(p.proxies, &proxy{
+			name:   n,
+			weight: pr.Weight,
+			doer:   newDoer(pr.URL),
+		})
+		metrics.ProxyHealthy.WithLabelValues(n).Set(1)
+	}
+	return p
+}
+
+func (p *Pool) Do(request *http.Request) (*http.Response, error) {
+	return p.do(request, "")
+}
+
+// WithSession returns the doer sending the requests of the session through the same proxy
+func (p *Pool) WithSession(key string) http.Doer {
+	return &sessionDoer{
+		pool: p,
+		key:  key,
+	}
+}
+
+func (p *Pool) do(request *http.Request, key string) (*http.Response, error) {
+	pr := p.pick(key)
+	res, err := pr.doer.Do(request)
+	p.observe(pr, err)
+	return res, err
+}
+
+// pick returns the proxy of the session, or picks one of the healthy proxies if the session has none yet
+// or its proxy has been ejected
+func (p *Pool) pick(key string) *proxy {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := p.now()
+	if key == "" {
+		return p.pickWeighted(now)
+	}
+	// the sessions of the ended events are forgotten
+	for k, s := range p.sessions {
+		if now.Sub(s.lastUsed) > p.sessionTTL {
+			delete(p.sessions, k)
+		}
+	}
+	if s, ok := p.sessions[key]; ok && p.isHealthy(s.proxy, now) {
+		s.lastUsed = now
+		return s.proxy
+	}
+	pr := p.pickWeighted(now)
+	p.sessions[key] = &session{
+		proxy:    pr,
+		lastUsed: now,
+	}
+	return pr
+}
+
+// pickWeighted picks one of the healthy proxies in proportion to their weights, the one returning the soonest
+// is picked if all of them are ejected
+func (p *Pool) pickWeighted(now time.Time) *proxy {
+	total := 0
+	for _, pr := range p.proxies {
+		if p.isHealthy(pr, now) {
+			total += pr.weight
+		}
+	}
+	if total == 0 {
+		next := p.proxies[0]
+		for _, pr := range p.proxies[1:] {
+			if pr.ejectedUntil.Before(next.ejectedUntil) {
+				next = pr
+			}
+		}
+		return next
+	}
+
+	r := p.random(total)
+	for _, pr := range p.proxies {
+		if !p.isHealthy(pr, now) {
+			continue
+		}
+		if r < pr.weight {
+			return pr
+		}
+		r -= pr.weight
+	}
+	return nil
+}
+
+// isHealthy checks whether the proxy is not ejected, the proxies whose ejection time has passed are restored
+func (p *Pool) isHealthy(pr *proxy, now time.Time) bool {
+	if !pr.ejected {
+		return true
+	}
+	if now.Before(pr.ejectedUntil) {
+		return false
+	}
+	pr.ejected = false
+	metrics.ProxyHealthy.WithLabelValues(pr.name).Set(1)
+	logrus.WithField("proxy", pr.name).Info("proxy restored")
+	return true
+}
+
+// observe records the result of the request sent through the proxy
+func (p *Pool) observe(pr *proxy, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := p.now()
+	var be *egress.BlockedError
+	switch {
+	case err == nil:
+		pr.failures = 0
+	case errors.As(err, &be):
+		// the proxy is left out at least until the upstream cools down
+		until := now.Add(p.ejectionTime)
+		if be.Until.After(until) {
+			until = be.Until
+		}
+		p.eject(pr, until, blockedEjection)
+	default:
+		if pr.failures++; pr.failures >= p.maxFailures {
+			p.eject(pr, now.Add(p.ejectionTime), failuresEjection)
+		}
+	}
+}
+
+func (p *Pool) eject(pr *proxy, until time.Time, reason string) {
+	if pr.ejected {
+		return
+	}
+	pr.ejected = true
+	pr.ejectedUntil = until
+	pr.failures = 0
+
+	metrics.ProxyHealthy.WithLabelValues(pr.name).Set(0)
+	metrics.ProxyEjections.WithLabelValues(pr.name, reason).Inc()
+	logrus.WithFields(logrus.Fields{
+		"proxy":  pr.name,
+		"reason": reason,
+		"until":  until,
+	}).Warn("proxy ejected")
+}
+
+type sessionDoer struct {
+	pool *Pool
+	key  string
+}
+
+func (d *sessionDoer) Do(request *http.Request) (*http.Response, error) {
+	return d.pool.do(request, d.key)
+}
+
+// getProxyName returns the proxy url without the credentials, so that it can be logged
+func getProxyName(proxyUrl string) string {
+	u, err := url.Parse(proxyUrl)
+	if err != nil {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
@@ -0,0 +1,169 @@
+package proxy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-ladbrokes/internal/egress"
+	"github.com/olafszymanski/int-sdk/http"
+	"github.com/stretchr/testify/require"
+)
+
+// testProxy stands in for the client sending the requests through the proxy
+type testProxy struct {
+	requests int
+	err      error
+}
+
+func (p *testProxy) Do(request *http.Request) (*http.Response, error) {
+	p.requests++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &http.Response{Status: 200}, nil
+}
+
+func newTestPool(t *testing.T) (*Pool, map[string]*testProxy, *time.Time) {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Proxy.Proxies = []config.Proxy{
+		{URL: "http://user:pass@a:8080", Weight: 1},
+		{URL: "socks5://b:1080", Weight: 3},
+	}
+	cfg.Proxy.MaxFailures = 2
+	cfg.Proxy.EjectionTime = time.Minute
+	cfg.Proxy.SessionTTL = 10 * time.Minute
+
+	var (
+		proxies = make(map[string]*testProxy)
+		now     = time.Now()
+	)
+	p := NewPool(cfg, func(proxyUrl string) http.Doer {
+		pr := &testProxy{}
+		proxies[getProxyName(proxyUrl)] = pr
+		return pr
+	})
+	p.now = func() time.Time { return now }
+	return p, proxies, &now
+}
+
+func TestPoolWeights(t *testing.T) {
+	p, proxies, _ := newTestPool(t)
+	for r := 0; r < 4; r++ {
+		r := r
+		p.random = func(n int) int {
+			require.Equal(t, 4, n)
+			return r
+		}
+		_, err := p.Do(&http.Request{})
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, proxies["http://a:8080"].requests)
+	require.Equal(t, 3, proxies["socks5://b:1080"].requests)
+}
+
+func TestPoolEjection(t *testing.T) {
+	errProxy := errors.New("proxy failed")
+
+	tests := []struct {
+		name string
+		err  error
+		// failed requests before the proxy is ejected
+		failures int
+		// the proxy is ejected for that long
+		ejection time.Duration
+	}{
+		{
+			name:     "repeated failures",
+			err:      errProxy,
+			failures: 2,
+			ejection: time.Minute,
+		},
+		{
+			name:     "blocked",
+			err:      &egress.BlockedError{Kind: egress.KindBan, Until: time.Now().Add(5 * time.Minute)},
+			failures: 1,
+			ejection: 5 * time.Minute,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				p, proxies, now = newTestPool(t)
+				a               = proxies["http://a:8080"]
+				b               = proxies["socks5://b:1080"]
+			)
+			// always picks the first healthy proxy
+			p.random = func(n int) int { return 0 }
+
+			a.err = test.err
+			for i := 0; i < test.failures; i++ {
+				_, err := p.Do(&http.Request{})
+				require.ErrorIs(t, err, test.err)
+			}
+			_, err := p.Do(&http.Request{})
+			require.NoError(t, err)
+			require.Equal(t, test.failures, a.requests)
+			require.Equal(t, 1, b.requests)
+
+			// every proxy is ejected, the one returning the soonest is used
+			b.err = errProxy
+			for i := 0; i < 2; i++ {
+				_, err := p.Do(&http.Request{})
+				require.Error(t, err)
+			}
+			p.Do(&http.Request{})
+			if test.ejection > time.Minute {
+				require.Equal(t, 4, b.requests)
+			} else {
+				require.Equal(t, test.failures+1, a.requests)
+			}
+
+			*now = now.Add(test.ejection)
+			a.err = nil
+			_, err = p.Do(&http.Request{})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPoolSessions(t *testing.T) {
+	p, proxies, now := newTestPool(t)
+	var (
+		a = proxies["http://a:8080"]
+		b = proxies["socks5://b:1080"]
+		r = 0
+	)
+	p.random = func(n int) int { return r % n }
+
+	s := p.WithSession("1")
+	_, err := s.Do(&http.Request{})
+	require.NoError(t, err)
+	// sticks to the proxy picked by the first request
+	r = 3
+	for i := 0; i < 3; i++ {
+		_, err := s.Do(&http.Request{})
+		require.NoError(t, err)
+	}
+	require.Equal(t, 4, a.requests)
+
+	// moves once the proxy is ejected
+	a.err = errors.New("proxy failed")
+	s.Do(&http.Request{})
+	s.Do(&http.Request{})
+	a.err = nil
+	_, err = s.Do(&http.Request{})
+	require.NoError(t, err)
+	require.Equal(t, 1, b.requests)
+
+	// the sessions not used are forgotten
+	*now = now.Add(11 * time.Minute)
+	r = 0
+	_, err = p.WithSession("2").Do(&http.Request{})
+	require.NoError(t, err)
+	require.NotContains(t, p.sessions, "1")
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"google.golang.org/grpc"
+)
+
+// Start serves the common integration service along with the Ladbrokes specific one on the same port
+// until the context is done
+func Start(ctx context.Context, integration pb.IntegrationServer, ladbrokes ladbrokesPb.LadbrokesServer, port string, opts ...grpc.ServerOption) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+	if err != nil {
+		return err
+	}
+
+	s := grpc.NewServer(opts...)
+	pb.RegisterIntegrationServer(s, integration)
+	ladbrokesPb.RegisterLadbrokesServer(s, ladbrokes)
+	go func() {
+		<-ctx.Done()
+		// the events streams never end on their own, they can't be waited for
+		s.Stop()
+	}()
+	return s.Serve(lis)
+}
@@ -0,0 +1,83 @@
+package shard
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// Membership registers the instance among the members sharing the keys, the members are kept in a Redis sorted set
+// scored by the time their registration expires at, so the members which stopped renewing it are left out
+type Membership struct {
+	client *redis.Client
+	id     string
+	ttl    time.Duration
+}
+
+func NewMembership(client *redis.Client, id string, ttl time.Duration) *Membership {
+	return &Membership{
+		client: client,
+		id:     id,
+		ttl:    ttl,
+	}
+}
+
+// Join keeps the instance registered until the context is done, the registration is removed afterwards
+func (m *Membership) Join(ctx context.Context, key string) error {
+	logger := logrus.WithFields(logrus.Fields{
+		"members":     key,
+		"instance_id": m.id,
+	})
+
+	for {
+		now := time.Now()
+		_, err := m.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			p.ZAdd(ctx, key, redis.Z{
+				Score:  float64(now.Add(m.ttl).UnixMilli()),
+				Member: m.id,
+			})
+			p.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.UnixMilli(), 10))
+			return nil
+		})
+		if err != nil && ctx.Err() == nil {
+			logger.WithError(err).Error("failed to renew membership")
+		}
+
+		select {
+		case <-ctx.Done():
+			m.leave(key)
+			return ctx.Err()
+		case <-time.After(m.ttl / 3):
+		}
+	}
+}
+
+// GetShard returns the shard of the instance among the current members
+func (m *Membership) GetShard(ctx context.Context, key string) (*Shard, error) {
+	ms, err := m.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	// the instance is a member even if its registration hasn't been stored yet
+	if !slices.Contains(ms, m.id) {
+		ms = append(ms, m.id)
+	}
+	return NewShard(m.id, ms), nil
+}
+
+func (m *Membership) leave(key string) {
+	// the context is already done, the registration has to be removed anyway
+	ctx, cancel := context.WithTimeout(context.Background(), m.ttl)
+	defer cancel()
+
+	if err := m.client.ZRem(ctx, key, m.id).Err(); err != nil {
+		logrus.WithError(err).WithField("members", key).Error("failed to leave members")
+	}
+}
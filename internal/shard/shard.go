@@ -0,0 +1,63 @@
+package shard
+
+import (
+	"fmt"
+	"hash/crc32"
+	"sort"
+)
+
+// number of points every member is placed on the ring at, the more of them the more evenly the keys are distributed
+const replicas = 128
+
+// Shard distributes keys among the members by consistent hashing, so that a membership change moves only the keys
+// of the joining or leaving member
+type Shard struct {
+	id     string
+	hashes []uint32
+	owners map[uint32]string
+}
+
+// NewShard returns the shard of the member with the given id
+func NewShard(id string, members []string) *Shard {
+	s := &Shard{
+		id:     id,
+		hashes: make([]uint32, 0, len(members)*replicas),
+		owners: make(map[uint32]string, len(members)*replicas),
+	}
+	for _, m := range members {
+		for i := 0; i < replicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(fmt.Sprintf("%s#%d", m, i)))
+			// on a collision the smaller id wins, so that every member resolves it the same way
+			if o, ok := s.owners[h]; ok && o < m {
+				continue
+			} else if !ok {
+				s.hashes = append(s.hashes, h)
+			}
+			s.owners[h] = m
+		}
+	}
+	sort.Slice(s.hashes, func(a, b int) bool {
+		return s.hashes[a] < s.hashes[b]
+	})
+	return s
+}
+
+// GetOwner returns the member owning the key, or an empty string if there are no members
+func (s *Shard) GetOwner(key string) string {
+	if len(s.hashes) == 0 {
+		return ""
+	}
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(s.hashes), func(i int) bool {
+		return s.hashes[i] >= h
+	})
+	if i == len(s.hashes) {
+		i = 0
+	}
+	return s.owners[s.hashes[i]]
+}
+
+// Owns reports whether the key is owned by the member the shard belongs to
+func (s *Shard) Owns(key string) bool {
+	return s.GetOwner(key) == s.id
+}
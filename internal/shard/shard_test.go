@@ -0,0 +1,75 @@
+package shard_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/shard"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardGetOwner(t *testing.T) {
+	tc := []struct {
+		name    string
+		members []string
+	}{
+		{
+			name:    "single member",
+			members: []string{"a"},
+		},
+		{
+			name:    "many members",
+			members: []string{"a", "b", "c", "d"},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			s := shard.NewShard(c.members[0], c.members)
+			reversed := make([]string, 0, len(c.members))
+			for i := len(c.members) - 1; i >= 0; i-- {
+				reversed = append(reversed, c.members[i])
+			}
+			r := shard.NewShard(c.members[0], reversed)
+
+			for _, k := range getKeys(1000) {
+				require.Contains(t, c.members, s.GetOwner(k))
+				// every member has to agree on the owner, no matter the order it got the members in
+				require.Equal(t, s.GetOwner(k), r.GetOwner(k))
+				require.Equal(t, s.GetOwner(k) == c.members[0], s.Owns(k))
+			}
+		})
+	}
+}
+
+func TestShardGetOwnerNoMembers(t *testing.T) {
+	s := shard.NewShard("a", nil)
+	require.Equal(t, "", s.GetOwner("1"))
+	require.False(t, s.Owns("1"))
+}
+
+func TestShardRebalance(t *testing.T) {
+	var (
+		before = shard.NewShard("a", []string{"a", "b", "c"})
+		after  = shard.NewShard("a", []string{"a", "b", "c", "d"})
+		moved  = 0
+	)
+	for _, k := range getKeys(1000) {
+		if before.GetOwner(k) == after.GetOwner(k) {
+			continue
+		}
+		// keys move only to the joining member
+		require.Equal(t, "d", after.GetOwner(k))
+		moved++
+	}
+	require.Greater(t, moved, 0)
+	require.Less(t, moved, 500)
+}
+
+func getKeys(n int) []string {
+	keys := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		keys = append(keys, fmt.Sprint(i))
+	}
+	return keys
+}
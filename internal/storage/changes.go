@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/broker"
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// SubscribeEventsChanges subscribes to the notifications published on every write of the events,
+// each received message is the hash of the changed events
+func (s *Storage) SubscribeEventsChanges(ctx context.Context) (*broker.Subscription, error) {
+	return s.broker.Subscribe(ctx, config.EventsChangesChannelKey)
+}
+
+func (s *Storage) publishEventsChange(ctx context.Context, hash string) error {
+	return s.broker.Publish(ctx, config.EventsChangesChannelKey, []byte(hash))
+}
+
+// changesCoalescer coalesces the change notifications of the frequent single event writes, e.g. the pushed updates,
+// every hash is notified at most once per interval, after the last write within it. Every notification invalidates
+// the whole cached hash, so notifying every write would leave nothing cached under the push load
+type changesCoalescer struct {
+	lock     sync.Mutex
+	interval time.Duration
+	// hashes with a notification scheduled
+	pending map[string]struct{}
+	publish func(ctx context.Context, hash string) error
+}
+
+func newChangesCoalescer(interval time.Duration, publish func(ctx context.Context, hash string) error) *changesCoalescer {
+	return &changesCoalescer{
+		interval: interval,
+		pending:  make(map[string]struct{}),
+		publish:  publish,
+	}
+}
+
+// notify schedules the change notification of the hash unless it's scheduled already,
+// the notification is published right away if the interval is 0
+func (c *changesCoalescer) notify(ctx context.Context, hash string) error {
+	if c.interval <= 0 {
+		return c.publish(ctx, hash)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.pending[hash]; ok {
+		return nil
+	}
+	c.pending[hash] = struct{}{}
+	time.AfterFunc(c.interval, func() {
+		c.lock.Lock()
+		delete(c.pending, hash)
+		c.lock.Unlock()
+
+		// the caches serve the events until they get stale if the notification is lost
+		if err := c.publish(context.Background(), hash); err != nil {
+			logrus.WithError(err).WithField("hash", hash).Error("failed to publish events change")
+		}
+	})
+	return nil
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventsChangesCoalesced(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		// notifications received after the writes
+		expected int
+	}{
+		{
+			name:     "every write",
+			interval: 0,
+			expected: 5,
+		},
+		{
+			name:     "coalesced",
+			interval: 50 * time.Millisecond,
+			expected: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				ctx  = context.Background()
+				s, _ = newTestStorage(t, test.interval)
+			)
+			sub, err := s.SubscribeEventsChanges(ctx)
+			require.NoError(t, err)
+			defer sub.Close()
+
+			for i := 0; i < 5; i++ {
+				require.NoError(t, s.StoreEvent(ctx, "LIVE_EVENTS_BASKETBALL", &pb.Event{ExternalId: "1"}))
+			}
+
+			received := 0
+			for {
+				rctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+				hash, err := sub.Receive(rctx)
+				cancel()
+				if err != nil {
+					break
+				}
+				require.Equal(t, "LIVE_EVENTS_BASKETBALL", string(hash))
+				received++
+			}
+			require.Equal(t, test.expected, received)
+		})
+	}
+}
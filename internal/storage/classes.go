@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	sdkStorage "github.com/olafszymanski/int-sdk/storage"
+)
+
+// GetEventsClasses returns the ids of the stored events classes mapped by the events ids,
+// the classes tell which of the pollers sharing the hash the events belong to
+func (s *Storage) GetEventsClasses(ctx context.Context, hash string) (map[string]string, error) {
+	raw, err := s.storage.GetMapValues(ctx, fmt.Sprintf(config.EventsClassesStorageKey, hash))
+	if err != nil {
+		if errors.Is(err, sdkStorage.ErrNotFound) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+
+	cls := make(map[string]string, len(raw))
+	for id, r := range raw {
+		cls[id] = string(r)
+	}
+	return cls, nil
+}
+
+func (s *Storage) StoreEventsClasses(ctx context.Context, hash string, classes map[string]string) error {
+	if len(classes) == 0 {
+		return nil
+	}
+	rawCls := make(map[string]any, len(classes))
+	for id, c := range classes {
+		rawCls[id] = c
+	}
+	return s.storage.SetMapValues(ctx, fmt.Sprintf(config.EventsClassesStorageKey, hash), rawCls)
+}
+
+func (s *Storage) deleteEventsClasses(ctx context.Context, hash string, ids []string) error {
+	err := s.storage.DeleteMapKeys(ctx, fmt.Sprintf(config.EventsClassesStorageKey, hash), ids)
+	if err != nil && !errors.Is(err, sdkStorage.ErrNotFound) {
+		return err
+	}
+	return nil
+}
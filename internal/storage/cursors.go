@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	sdkStorage "github.com/olafszymanski/int-sdk/storage"
+)
+
+// GetUpdatesCursor returns the request body parts the updates subscription of the event continues from,
+// it returns nil if there is no cursor stored or it has expired
+func (s *Storage) GetUpdatesCursor(ctx context.Context, id string) ([]string, error) {
+	raw, err := s.storage.Get(ctx, fmt.Sprintf(config.UpdatesCursorStorageKey, id))
+	if err != nil {
+		if errors.Is(err, sdkStorage.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var parts []string
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecode, err)
+	}
+	return parts, nil
+}
+
+// StoreUpdatesCursor stores the request body parts of the updates subscription of the event, nil parts
+// invalidate the stored ones
+func (s *Storage) StoreUpdatesCursor(ctx context.Context, id string, parts []string, expiration time.Duration) error {
+	raw, err := json.Marshal(parts)
+	if err != nil {
+		return err
+	}
+	return s.storage.Set(ctx, fmt.Sprintf(config.UpdatesCursorStorageKey, id), raw, expiration)
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/broker"
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type DeltasSubscription struct {
+	subscription *broker.Subscription
+}
+
+func (s *Storage) PublishDeltas(ctx context.Context, channel string, deltas []*ladbrokesPb.Delta) error {
+	if len(deltas) == 0 {
+		return nil
+	}
+	raw, err := proto.Marshal(&ladbrokesPb.Deltas{
+		Deltas: deltas,
+		Time:   timestamppb.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return s.broker.Publish(ctx, channel, raw)
+}
+
+func (s *Storage) SubscribeDeltas(ctx context.Context, channel string) (*DeltasSubscription, error) {
+	sub, err := s.broker.Subscribe(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+	return &DeltasSubscription{
+		subscription: sub,
+	}, nil
+}
+
+// Receive blocks until the next published deltas are received or the context is done
+func (s *DeltasSubscription) Receive(ctx context.Context) (*ladbrokesPb.Deltas, error) {
+	raw, err := s.subscription.Receive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ds ladbrokesPb.Deltas
+	if err := proto.Unmarshal(raw, &ds); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecode, err)
+	}
+	return &ds, nil
+}
+
+func (s *DeltasSubscription) Close() error {
+	return s.subscription.Close()
+}
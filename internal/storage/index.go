@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	sdkStorage "github.com/olafszymanski/int-sdk/storage"
+)
+
+// EventIndex holds the fields events can be queried by, so that filtering them doesn't require unmarshalling every event
+type EventIndex struct {
+	League      string          `json:"league"`
+	MarketTypes []pb.MarketType `json:"market_types"`
+	StartTime   int64           `json:"start_time"`
+}
+
+// GetEventsIndex returns the index of the events stored in the hash, like the events it's not found until they are synced
+func (s *Storage) GetEventsIndex(ctx context.Context, hash string) (map[string]*EventIndex, error) {
+	raw, err := s.storage.GetMapValues(ctx, fmt.Sprintf(config.EventsIndexStorageKey, hash))
+	if err != nil {
+		return nil, err
+	}
+	// a missing index can't be told apart from an empty one, unless the events have never been synced
+	if len(raw) == 0 {
+		synced, err := s.isEventsSynced(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if !synced {
+			return nil, sdkStorage.ErrNotFound
+		}
+	}
+
+	idx := make(map[string]*EventIndex, len(raw))
+	for id, r := range raw {
+		var i EventIndex
+		if err := json.Unmarshal(r, &i); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrDecode, err)
+		}
+		idx[id] = &i
+	}
+	return idx, nil
+}
+
+// GetEventsByIds returns the stored events with the given ids in a single round trip, ids of missing events are skipped
+func (s *Storage) GetEventsByIds(ctx context.Context, hash string, ids []string) ([]*pb.Event, error) {
+	evs := make([]*pb.Event, 0, len(ids))
+	if len(ids) == 0 {
+		return evs, nil
+	}
+
+	raw, err := s.client.HMGet(ctx, hash, ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range raw {
+		// missing fields are returned as nils
+		str, ok := r.(string)
+		if !ok {
+			continue
+		}
+		var ev pb.Event
+		if err := json.Unmarshal([]byte(str), &ev); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrDecode, err)
+		}
+		evs = append(evs, &ev)
+	}
+	return evs, nil
+}
+
+func (s *Storage) storeEventsIndex(ctx context.Context, hash string, events []*pb.Event) error {
+	rawIdx := make(map[string]any, len(events))
+	for _, e := range events {
+		raw, err := json.Marshal(newEventIndex(e))
+		if err != nil {
+			return err
+		}
+		rawIdx[e.ExternalId] = raw
+	}
+	return s.storage.SetMapValues(ctx, fmt.Sprintf(config.EventsIndexStorageKey, hash), rawIdx)
+}
+
+func (s *Storage) deleteEventsIndex(ctx context.Context, hash string, ids []string) error {
+	err := s.storage.DeleteMapKeys(ctx, fmt.Sprintf(config.EventsIndexStorageKey, hash), ids)
+	if err != nil && !errors.Is(err, sdkStorage.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func newEventIndex(event *pb.Event) *EventIndex {
+	mtps := make([]pb.MarketType, 0, len(event.Markets))
+	for _, m := range event.Markets {
+		mtps = append(mtps, m.Type)
+	}
+	return &EventIndex{
+		League:      event.League,
+		MarketTypes: mtps,
+		StartTime:   event.StartTime.AsTime().Unix(),
+	}
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	sdkStorage "github.com/olafszymanski/int-sdk/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const testHash = "LIVE_EVENTS_BASKETBALL"
+
+func TestGetEventsIndex(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		s, _      = newTestStorage(t, 0)
+		startTime = time.Unix(1700000000, 0)
+	)
+	_, err := s.GetEventsIndex(ctx, testHash)
+	require.ErrorIs(t, err, sdkStorage.ErrNotFound)
+	require.NoError(t, s.MarkEventsSynced(ctx, testHash))
+	idx, err := s.GetEventsIndex(ctx, testHash)
+	require.NoError(t, err)
+	require.Empty(t, idx)
+
+	require.NoError(t, s.StoreEvents(ctx, testHash, []*pb.Event{
+		{
+			ExternalId: "1",
+			League:     "NBA",
+			StartTime:  timestamppb.New(startTime),
+			Markets:    []*pb.Market{{Type: pb.MarketType_MONEYLINE}, {Type: pb.MarketType_TOTAL_POINTS}},
+		},
+		{
+			ExternalId: "2",
+			League:     "Euroleague",
+			StartTime:  timestamppb.New(startTime.Add(time.Hour)),
+		},
+	}))
+
+	idx, err = s.GetEventsIndex(ctx, testHash)
+	require.NoError(t, err)
+	require.Equal(t, map[string]*EventIndex{
+		"1": {
+			League:      "NBA",
+			MarketTypes: []pb.MarketType{pb.MarketType_MONEYLINE, pb.MarketType_TOTAL_POINTS},
+			StartTime:   startTime.Unix(),
+		},
+		"2": {
+			League:      "Euroleague",
+			MarketTypes: []pb.MarketType{},
+			StartTime:   startTime.Add(time.Hour).Unix(),
+		},
+	}, idx)
+
+	// the index follows the deleted events
+	require.NoError(t, s.DeleteEvents(ctx, testHash, []string{"2"}))
+	idx, err = s.GetEventsIndex(ctx, testHash)
+	require.NoError(t, err)
+	require.Len(t, idx, 1)
+	require.Contains(t, idx, "1")
+}
+
+func TestGetEventsByIds(t *testing.T) {
+	tests := []struct {
+		name        string
+		ids         []string
+		expectedIds []string
+	}{
+		{
+			name:        "none",
+			ids:         []string{},
+			expectedIds: []string{},
+		},
+		{
+			name:        "ordered as requested",
+			ids:         []string{"3", "1"},
+			expectedIds: []string{"3", "1"},
+		},
+		{
+			name:        "missing skipped",
+			ids:         []string{"1", "4", "2"},
+			expectedIds: []string{"1", "2"},
+		},
+	}
+
+	var (
+		ctx  = context.Background()
+		s, _ = newTestStorage(t, 0)
+	)
+	require.NoError(t, s.StoreEvents(ctx, testHash, []*pb.Event{{ExternalId: "1"}, {ExternalId: "2"}, {ExternalId: "3"}}))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			evs, err := s.GetEventsByIds(ctx, testHash, test.ids)
+			require.NoError(t, err)
+			ids := make([]string, 0, len(evs))
+			for _, e := range evs {
+				ids = append(ids, e.ExternalId)
+			}
+			require.Equal(t, test.expectedIds, ids)
+		})
+	}
+}
+
+func TestGetEventsByIdsDecodeFailed(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		s, mr = newTestStorage(t, 0)
+	)
+	mr.HSet(testHash, "1", "{")
+
+	mr.HSet(fmt.Sprintf(config.EventsIndexStorageKey, testHash), "1", "{")
+
+	_, err := s.GetEventsByIds(ctx, testHash, []string{"1"})
+	require.ErrorIs(t, err, ErrDecode)
+	_, err = s.GetEventsIndex(ctx, testHash)
+	require.ErrorIs(t, err, ErrDecode)
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	"github.com/olafszymanski/int-sdk/integration/pb"
+	sdkStorage "github.com/olafszymanski/int-sdk/storage"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// moves the event with its index and class from one hash to another, publishing the changes of both,
+	// it returns whether the event was removed from the source and added to the destination
+	moveEventScript = redis.NewScript(`
+local class = redis.call("HGET", KEYS[3], ARGV[1])
+local removed = redis.call("HDEL", KEYS[1], ARGV[1])
+redis.call("HDEL", KEYS[2], ARGV[1])
+redis.call("HDEL", KEYS[3], ARGV[1])
+local added = redis.call("HSET", KEYS[4], ARGV[1], ARGV[2])
+redis.call("HSET", KEYS[5], ARGV[1], ARGV[3])
+if class then
+	redis.call("HSET", KEYS[6], ARGV[1], class)
+end
+if removed == 1 then
+	redis.call("PUBLISH", ARGV[4], ARGV[5])
+end
+redis.call("PUBLISH", ARGV[4], ARGV[6])
+return {removed, added}
+`)
+	// stores the events with their indexes, except the ones present in the excluded hash, it returns the ids of the stored ones
+	storeEventsExcludingScript = redis.NewScript(`
+local stored = {}
+for i = 1, #ARGV, 3 do
+	if redis.call("HEXISTS", KEYS[3], ARGV[i]) == 0 then
+		redis.call("HSET", KEYS[1], ARGV[i], ARGV[i + 1])
+		redis.call("HSET", KEYS[2], ARGV[i], ARGV[i + 2])
+		table.insert(stored, ARGV[i])
+	end
+end
+return stored
+`)
+	// returns the event from the first of the hashes storing it
+	getFirstEventScript = redis.NewScript(`
+for i = 1, #KEYS do
+	local raw = redis.call("HGET", KEYS[i], ARGV[1])
+	if raw then
+		return raw
+	end
+end
+return false
+`)
+)
+
+// GetFirstEvent atomically looks the event up in the hashes in their order and returns the first one found,
+// so that the event moved between them in the meantime is never missed
+func (s *Storage) GetFirstEvent(ctx context.Context, hashes []string, id string) (*pb.Event, error) {
+	raw, err := getFirstEventScript.Run(ctx, s.client, hashes, id).Text()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, sdkStorage.ErrNotFound
+		}
+		return nil, err
+	}
+
+	var ev pb.Event
+	if err := json.Unmarshal([]byte(raw), &ev); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecode, err)
+	}
+	return &ev, nil
+}
+
+// MoveEvent atomically moves the event from one hash to another, so that it's never missing from both nor present in both.
+// The event is stored in the destination even if it's not present in the source, it returns whether it was removed
+// from the source and whether it wasn't present in the destination yet
+func (s *Storage) MoveEvent(ctx context.Context, from, to string, event *pb.Event) (bool, bool, error) {
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return false, false, err
+	}
+	rawIdx, err := json.Marshal(newEventIndex(event))
+	if err != nil {
+		return false, false, err
+	}
+
+	res, err := moveEventScript.Run(ctx, s.client, []string{
+		from,
+		fmt.Sprintf(config.EventsIndexStorageKey, from),
+		fmt.Sprintf(config.EventsClassesStorageKey, from),
+		to,
+		fmt.Sprintf(config.EventsIndexStorageKey, to),
+		fmt.Sprintf(config.EventsClassesStorageKey, to),
+	}, event.ExternalId, raw, rawIdx, config.EventsChangesChannelKey, from, to).Int64Slice()
+	if err != nil {
+		return false, false, err
+	}
+	return res[0] == 1, res[1] == 1, nil
+}
+
+// StoreEventsExcluding atomically stores the events which are not present in the excluded hash, e.g. the pre-match events
+// which have been moved to the live ones already, it returns the stored events
+func (s *Storage) StoreEventsExcluding(ctx context.Context, hash, excluded string, events []*pb.Event) ([]*pb.Event, error) {
+	if len(events) == 0 {
+		return events, nil
+	}
+	args := make([]any, 0, len(events)*3)
+	byId := make(map[string]*pb.Event, len(events))
+	for _, e := range events {
+		raw, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		rawIdx, err := json.Marshal(newEventIndex(e))
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, e.ExternalId, raw, rawIdx)
+		byId[e.ExternalId] = e
+	}
+
+	ids, err := storeEventsExcludingScript.Run(ctx, s.client, []string{
+		hash,
+		fmt.Sprintf(config.EventsIndexStorageKey, hash),
+		excluded,
+	}, args...).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+	evs := make([]*pb.Event, 0, len(ids))
+	for _, id := range ids {
+		evs = append(evs, byId[id])
+	}
+	if len(evs) > 0 {
+		if err := s.publishEventsChange(ctx, hash); err != nil {
+			return nil, err
+		}
+	}
+	return evs, nil
+}
@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/broker"
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+)
+
+// PublishEventRefresh requests the refresh of the event stored in the hash, it's handled by the poller owning the event
+func (s *Storage) PublishEventRefresh(ctx context.Context, hash, id string) error {
+	return s.broker.Publish(ctx, fmt.Sprintf(config.EventsRefreshChannelKey, hash), []byte(id))
+}
+
+// SubscribeEventsRefreshes subscribes to the refresh requests of the events stored in the hash,
+// each received message is the id of the event to refresh
+func (s *Storage) SubscribeEventsRefreshes(ctx context.Context, hash string) (*broker.Subscription, error) {
+	return s.broker.Subscribe(ctx, fmt.Sprintf(config.EventsRefreshChannelKey, hash))
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	sdkStorage "github.com/olafszymanski/int-sdk/storage"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	resultsEventIdField   = "event_external_id"
+	resultsUpdatedAtField = "updated_at"
+)
+
+// records the results of the outcomes as the fields of the hash, the later ones replace the earlier results of the same
+// outcomes, the results are marked updated and their expiration is reset only if any of them changed.
+// It returns whether any of them changed
+var recordResultsScript = redis.NewScript(`
+local changed = false
+for i = 4, #ARGV, 2 do
+	if redis.call("HGET", KEYS[1], ARGV[i]) ~= ARGV[i + 1] then
+		redis.call("HSET", KEYS[1], ARGV[i], ARGV[i + 1])
+		changed = true
+	end
+end
+if not changed then
+	return 0
+end
+redis.call("HSET", KEYS[1], "` + resultsEventIdField + `", ARGV[1], "` + resultsUpdatedAtField + `", ARGV[2])
+if tonumber(ARGV[3]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[3])
+end
+return 1
+`)
+
+func (s *Storage) GetResults(ctx context.Context, key string) (*ladbrokesPb.Results, error) {
+	raw, err := s.storage.GetMapValues(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, sdkStorage.ErrNotFound
+	}
+
+	res := &ladbrokesPb.Results{
+		EventExternalId: string(raw[resultsEventIdField]),
+		Outcomes:        make([]*ladbrokesPb.OutcomeResult, 0, len(raw)),
+	}
+	for f, r := range raw {
+		switch f {
+		case resultsEventIdField:
+		case resultsUpdatedAtField:
+			t, err := time.Parse(time.RFC3339Nano, string(r))
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrDecode, err)
+			}
+			res.UpdatedAt = timestamppb.New(t)
+		default:
+			var o ladbrokesPb.OutcomeResult
+			if err := json.Unmarshal(r, &o); err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrDecode, err)
+			}
+			res.Outcomes = append(res.Outcomes, &o)
+		}
+	}
+	sort.Slice(res.Outcomes, func(a, b int) bool {
+		return res.Outcomes[a].ExternalId < res.Outcomes[b].ExternalId
+	})
+	return res, nil
+}
+
+// RecordResults atomically merges the results of the outcomes into the stored results of the event, every outcome
+// is stored on its own, so that the results recorded at the same time by the other instances are never lost.
+// If expiration is 0, the results will not expire. It returns whether any of them changed
+func (s *Storage) RecordResults(ctx context.Context, key, id string, outcomes []*ladbrokesPb.OutcomeResult, expiration time.Duration) (bool, error) {
+	if len(outcomes) == 0 {
+		return false, nil
+	}
+	args := make([]any, 0, 3+len(outcomes)*2)
+	args = append(args, id, time.Now().UTC().Format(time.RFC3339Nano), expiration.Milliseconds())
+	for _, o := range outcomes {
+		raw, err := json.Marshal(o)
+		if err != nil {
+			return false, err
+		}
+		args = append(args, o.ExternalId, raw)
+	}
+
+	res, err := recordResultsScript.Run(ctx, s.client, []string{key}, args...).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// GetPendingResults returns the times the events waiting for their results ended at, mapped by the events ids
+func (s *Storage) GetPendingResults(ctx context.Context, key string) (map[string]time.Time, error) {
+	raw, err := s.storage.GetMapValues(ctx, key)
+	if err != nil {
+		if errors.Is(err, sdkStorage.ErrNotFound) {
+			return map[string]time.Time{}, nil
+		}
+		return nil, err
+	}
+
+	pending := make(map[string]time.Time, len(raw))
+	for id, r := range raw {
+		t, err := time.Parse(time.RFC3339, string(r))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrDecode, err)
+		}
+		pending[id] = t
+	}
+	return pending, nil
+}
+
+// AddPendingResults marks the events as ended and waiting for their results
+func (s *Storage) AddPendingResults(ctx context.Context, key string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	now := []byte(time.Now().UTC().Format(time.RFC3339))
+	raw := make(map[string]any, len(ids))
+	for _, id := range ids {
+		raw[id] = now
+	}
+	return s.storage.SetMapValues(ctx, key, raw)
+}
+
+func (s *Storage) DeletePendingResults(ctx context.Context, key string, ids []string) error {
+	err := s.storage.DeleteMapKeys(ctx, key, ids)
+	if err != nil && !errors.Is(err, sdkStorage.ErrNotFound) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	sdkStorage "github.com/olafszymanski/int-sdk/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordResults(t *testing.T) {
+	const key = "RESULTS_OUTCOMES_1"
+
+	var (
+		ctx   = context.Background()
+		s, mr = newTestStorage(t, 0)
+	)
+	_, err := s.GetResults(ctx, key)
+	require.ErrorIs(t, err, sdkStorage.ErrNotFound)
+
+	changed, err := s.RecordResults(ctx, key, "1", []*ladbrokesPb.OutcomeResult{
+		{ExternalId: "10", MarketExternalId: "100", Result: "W"},
+	}, time.Hour)
+	require.NoError(t, err)
+	require.True(t, changed)
+	res, err := s.GetResults(ctx, key)
+	require.NoError(t, err)
+	updatedAt := res.UpdatedAt.AsTime()
+
+	mr.SetTTL(key, time.Minute)
+	changed, err = s.RecordResults(ctx, key, "1", []*ladbrokesPb.OutcomeResult{
+		{ExternalId: "10", MarketExternalId: "100", Result: "W"},
+	}, time.Hour)
+	require.NoError(t, err)
+	require.False(t, changed)
+	// the unchanged results are neither updated nor kept for longer
+	require.Equal(t, time.Minute, mr.TTL(key))
+
+	// the outcomes recorded by the other instances are merged instead of being replaced
+	changed, err = s.RecordResults(ctx, key, "1", []*ladbrokesPb.OutcomeResult{
+		{ExternalId: "11", MarketExternalId: "100", Result: "L", Settled: true},
+	}, time.Hour)
+	require.NoError(t, err)
+	require.True(t, changed)
+	changed, err = s.RecordResults(ctx, key, "1", []*ladbrokesPb.OutcomeResult{
+		{ExternalId: "10", MarketExternalId: "100", Result: "W", Settled: true},
+	}, time.Hour)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, time.Hour, mr.TTL(key))
+
+	res, err = s.GetResults(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, "1", res.EventExternalId)
+	require.False(t, res.UpdatedAt.AsTime().Before(updatedAt))
+	require.Len(t, res.Outcomes, 2)
+	require.Equal(t, "10", res.Outcomes[0].ExternalId)
+	require.True(t, res.Outcomes[0].Settled)
+	require.Equal(t, "11", res.Outcomes[1].ExternalId)
+}
@@ -3,19 +3,39 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/olafszymanski/int-ladbrokes/internal/broker"
+	"github.com/olafszymanski/int-ladbrokes/internal/config"
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
 	"github.com/olafszymanski/int-sdk/integration/pb"
 	sdkStorage "github.com/olafszymanski/int-sdk/storage"
+	"github.com/redis/go-redis/v9"
 )
 
+var ErrDecode = fmt.Errorf("decoding stored data failed")
+
 type Storage struct {
 	storage sdkStorage.Storager
+	broker  *broker.Broker
+	// runs the writes which have to be atomic across the hashes
+	client *redis.Client
+	// notifies the single event writes
+	changes *changesCoalescer
 }
 
-func NewStorage(storage sdkStorage.Storager) *Storage {
-	return &Storage{
+// NewStorage returns the storage, the changes of the single events are notified at most once per changes interval,
+// while the rest of the writes are notified right away
+func NewStorage(storage sdkStorage.Storager, broker *broker.Broker, client *redis.Client, changesInterval time.Duration) *Storage {
+	s := &Storage{
 		storage: storage,
+		broker:  broker,
+		client:  client,
 	}
+	s.changes = newChangesCoalescer(changesInterval, s.publishEventsChange)
+	return s
 }
 
 func (s *Storage) GetClasses(ctx context.Context, key string) ([]byte, error) {
@@ -38,7 +58,7 @@ func (s *Storage) GetEvent(ctx context.Context, hash, id string) (*pb.Event, err
 
 	var ev pb.Event
 	if err := json.Unmarshal(raw, &ev); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %s", ErrDecode, err)
 	}
 	return &ev, nil
 }
@@ -48,7 +68,13 @@ func (s *Storage) StoreEvent(ctx context.Context, hash string, event *pb.Event)
 	if err != nil {
 		return err
 	}
-	return s.storage.SetMapValue(ctx, hash, event.ExternalId, raw)
+	if err := s.storage.SetMapValue(ctx, hash, event.ExternalId, raw); err != nil {
+		return err
+	}
+	if err := s.storeEventsIndex(ctx, hash, []*pb.Event{event}); err != nil {
+		return err
+	}
+	return s.changes.notify(ctx, hash)
 }
 
 func (s *Storage) GetEvents(ctx context.Context, hash string) ([]*pb.Event, error) {
@@ -56,12 +82,22 @@ func (s *Storage) GetEvents(ctx context.Context, hash string) ([]*pb.Event, erro
 	if err != nil {
 		return nil, err
 	}
+	// a missing hash can't be told apart from an empty one, unless the events have never been synced
+	if len(raw) == 0 {
+		synced, err := s.isEventsSynced(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if !synced {
+			return nil, sdkStorage.ErrNotFound
+		}
+	}
 
 	evs := make([]*pb.Event, 0, len(raw))
 	for _, r := range raw {
 		var ev pb.Event
 		if err := json.Unmarshal(r, &ev); err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %s", ErrDecode, err)
 		}
 		evs = append(evs, &ev)
 	}
@@ -77,44 +113,118 @@ func (s *Storage) StoreEvents(ctx context.Context, hash string, events []*pb.Eve
 		}
 		rawEvs[e.ExternalId] = raw
 	}
-	return s.storage.SetMapValues(ctx, hash, rawEvs)
+	if err := s.storage.SetMapValues(ctx, hash, rawEvs); err != nil {
+		return err
+	}
+	if err := s.storeEventsIndex(ctx, hash, events); err != nil {
+		return err
+	}
+	return s.publishEventsChange(ctx, hash)
 }
 
 func (s *Storage) StoreNewEvents(ctx context.Context, hash string, events []*pb.Event) error {
-	ids, err := s.GetEventsIds(ctx, hash)
+	new, err := s.GetNewEvents(ctx, hash, events)
 	if err != nil {
 		return err
 	}
-	new := getNewEvents(events, ids)
 	if len(new) == 0 {
 		return nil
 	}
 	return s.StoreEvents(ctx, hash, new)
 }
 
+// GetNewEvents returns the events which are not stored in the hash yet
+func (s *Storage) GetNewEvents(ctx context.Context, hash string, events []*pb.Event) ([]*pb.Event, error) {
+	ids, err := s.GetEventsIds(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return getNewEvents(events, ids), nil
+}
+
 func (s *Storage) DeleteEvents(ctx context.Context, hash string, ids []string) error {
 	if err := s.storage.DeleteMapKeys(ctx, hash, ids); err != nil {
 		return err
 	}
-	return nil
+	if err := s.deleteEventsIndex(ctx, hash, ids); err != nil {
+		return err
+	}
+	if err := s.deleteEventsClasses(ctx, hash, ids); err != nil {
+		return err
+	}
+	return s.publishEventsChange(ctx, hash)
 }
 
 func (s *Storage) GetEventsIds(ctx context.Context, hash string) ([]string, error) {
 	return s.storage.GetMapKeys(ctx, hash)
 }
 
-func (s *Storage) RemoveMissingEvents(ctx context.Context, hash string, events []*pb.Event) error {
-	curr, err := s.GetEventsIds(ctx, hash)
+func (s *Storage) RemoveMissingEvents(ctx context.Context, hash string, events []*pb.Event, owns func(id string) bool) error {
+	miss, err := s.GetMissingEventsIds(ctx, hash, events, owns)
 	if err != nil {
 		return err
 	}
-	miss := getMissingEventsIds(events, curr)
 	if len(miss) == 0 {
 		return nil
 	}
 	return s.DeleteEvents(ctx, hash, miss)
 }
 
+// GetMissingEventsIds returns the ids of the stored events which are not present in the given events,
+// only the events owned by the caller are considered, the rest of them are kept up to date by the other pollers
+func (s *Storage) GetMissingEventsIds(ctx context.Context, hash string, events []*pb.Event, owns func(id string) bool) ([]string, error) {
+	curr, err := s.GetEventsIds(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	owned := make([]string, 0, len(curr))
+	for _, id := range curr {
+		if owns(id) {
+			owned = append(owned, id)
+		}
+	}
+	return getMissingEventsIds(events, owned), nil
+}
+
+// MarkEventsSynced marks the events stored in the hash as synced with Ladbrokes, even if there are none of them
+func (s *Storage) MarkEventsSynced(ctx context.Context, hash string) error {
+	return s.storage.Set(ctx, fmt.Sprintf(config.EventsSyncedStorageKey, hash), time.Now().UTC().Format(time.RFC3339), 0)
+}
+
+func (s *Storage) isEventsSynced(ctx context.Context, hash string) (bool, error) {
+	_, err := s.storage.Get(ctx, fmt.Sprintf(config.EventsSyncedStorageKey, hash))
+	if err != nil {
+		if errors.Is(err, sdkStorage.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *Storage) GetClosingLine(ctx context.Context, key string) (*ladbrokesPb.ClosingLine, error) {
+	raw, err := s.storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var cl ladbrokesPb.ClosingLine
+	if err := json.Unmarshal(raw, &cl); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecode, err)
+	}
+	return &cl, nil
+}
+
+// StoreClosingLine stores the closing line only if it's not stored yet, so that it's captured at most once
+// even by the concurrent capturers, it returns whether it was stored. If expiration is 0, the closing line will not expire.
+func (s *Storage) StoreClosingLine(ctx context.Context, key string, closingLine *ladbrokesPb.ClosingLine, expiration time.Duration) (bool, error) {
+	raw, err := json.Marshal(closingLine)
+	if err != nil {
+		return false, err
+	}
+	return s.client.SetNX(ctx, key, raw, expiration).Result()
+}
+
 func getNewEvents(events []*pb.Event, currentEventsIds []string) []*pb.Event {
 	evs := make([]*pb.Event, 0)
 	for _, event := range events {
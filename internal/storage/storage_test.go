@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/olafszymanski/int-ladbrokes/internal/broker"
+	sdkStorage "github.com/olafszymanski/int-sdk/storage"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStorage(t *testing.T, changesInterval time.Duration) (*Storage, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	r, err := sdkStorage.NewRedisStorage(context.Background(), mr.Addr(), "")
+	require.NoError(t, err)
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		r.Close()
+		rc.Close()
+	})
+	return NewStorage(r, broker.NewBroker(rc), rc, changesInterval), mr
+}
@@ -28,7 +28,11 @@ func getName(name string, participants []*pb.Participant) (string, error) {
 	return name, nil
 }
 
-func isLive(isStarted string) (bool, error) {
+// isLive checks whether the event has started, the events which are off are live even if not flagged as started yet
+func isLive(isStarted, rawIsOffCode string) (bool, error) {
+	if rawIsOffCode == model.RawIsOffYesCode {
+		return true, nil
+	}
 	if isStarted == "" {
 		return false, nil
 	}
@@ -43,6 +43,22 @@ func getMarkets(event *model.Event, participantsOutcomeTypes map[string]pb.Outco
 	return markets, unhandledMarketTypes, nil
 }
 
+// getUnhandledMarketsIds returns the ids of the markets of the unhandled types and of their outcomes
+func getUnhandledMarketsIds(event *model.Event) map[string]struct{} {
+	ids := make(map[string]struct{})
+	for _, c := range event.Children {
+		mr := &c.Market
+		if _, ok := mapping.MarketTypes[mr.TemplateMarketName]; ok {
+			continue
+		}
+		ids[mr.ID] = struct{}{}
+		for _, oc := range mr.Children {
+			ids[oc.Outcome.ID] = struct{}{}
+		}
+	}
+	return ids
+}
+
 func isPlayerMarket(market *model.Market) bool {
 	return market.TemplateMarketName == mapping.PlayerTotalPointsMarketType ||
 		market.TemplateMarketName == mapping.PlayerTotalAssistsMarketType ||
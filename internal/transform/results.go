@@ -0,0 +1,96 @@
+package transform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/model"
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+)
+
+// noResultCode is sent in place of the result of the outcomes which haven't been resulted yet
+const noResultCode = "-"
+
+type EventResults struct {
+	Outcomes []*ladbrokesPb.OutcomeResult
+	// all of the markets of the event have been resulted
+	Resulted bool
+}
+
+// TransformResults transforms the resulted outcomes of the events mapped by the events ids,
+// the outcomes without a result yet are skipped
+func TransformResults(rawData []byte) (map[string]*EventResults, error) {
+	var root model.ResultsRoot
+	if err := json.Unmarshal(rawData, &root); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecodeResponse, err)
+	}
+
+	res := make(map[string]*EventResults, len(root.SSResponse.Children))
+	for _, c := range root.SSResponse.Children {
+		ev := c.ResultedEvent
+		ors := make([]*ladbrokesPb.OutcomeResult, 0)
+		for _, mc := range ev.Children {
+			for _, oc := range mc.ResultedMarket.Children {
+				o := oc.ResultedOutcome
+				if !hasResult(o.ResultCode, o.FbResult) {
+					continue
+				}
+				settled, err := parseOptionalBool(o.IsSettled)
+				if err != nil {
+					return nil, err
+				}
+				mid := o.MarketID
+				if mid == "" {
+					mid = mc.ResultedMarket.ID
+				}
+				ors = append(ors, &ladbrokesPb.OutcomeResult{
+					ExternalId:       o.ID,
+					MarketExternalId: mid,
+					Result:           o.ResultCode,
+					FbResult:         o.FbResult,
+					Settled:          settled,
+				})
+			}
+		}
+		resulted, err := parseOptionalBool(ev.IsResulted)
+		if err != nil {
+			return nil, err
+		}
+		res[ev.ID] = &EventResults{
+			Outcomes: ors,
+			Resulted: resulted,
+		}
+	}
+	return res, nil
+}
+
+// TransformSelectionResult transforms the result of the outcome carried by its selection update,
+// it returns nil if the outcome hasn't been resulted yet
+func TransformSelectionResult(id string, update *model.SelectionUpdate) *ladbrokesPb.OutcomeResult {
+	if !hasResult(update.Result, update.FbResult) {
+		return nil
+	}
+	return &ladbrokesPb.OutcomeResult{
+		ExternalId:       id,
+		MarketExternalId: strconv.Itoa(update.EvMktID),
+		Result:           update.Result,
+		FbResult:         update.FbResult,
+		Settled:          update.Settled == model.YesUpdateFlag,
+	}
+}
+
+func hasResult(result, fbResult string) bool {
+	return (result != "" && result != noResultCode) || (fbResult != "" && fbResult != noResultCode)
+}
+
+func parseOptionalBool(raw string) (bool, error) {
+	if raw == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", ErrParseBool, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,59 @@
+package transform_test
+
+import (
+	_ "embed"
+	"testing"
+
+	"github.com/olafszymanski/int-ladbrokes/internal/transform"
+	ladbrokesPb "github.com/olafszymanski/int-ladbrokes/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+//go:embed testdata/results/success.json
+var resultsSuccessData []byte
+
+func TestTransformResults(t *testing.T) {
+	tc := []struct {
+		name        string
+		data        []byte
+		results     map[string]*transform.EventResults
+		expectedErr error
+	}{
+		{
+			name:        "invalid",
+			data:        []byte(`{`),
+			expectedErr: transform.ErrDecodeResponse,
+		},
+		{
+			name: "success",
+			data: resultsSuccessData,
+			results: map[string]*transform.EventResults{
+				"244772570": {
+					Outcomes: []*ladbrokesPb.OutcomeResult{
+						{ExternalId: "2372614298", MarketExternalId: "581234001", Result: "W", Settled: true},
+						// the market id is taken from the market if missing
+						{ExternalId: "2372614299", MarketExternalId: "581234001", Result: "L", Settled: true},
+					},
+					Resulted: true,
+				},
+			},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			res, err := transform.TransformResults(c.data)
+			require.ErrorIs(t, err, c.expectedErr)
+			require.Len(t, res, len(c.results))
+			for id, r := range c.results {
+				require.Contains(t, res, id)
+				require.Equal(t, r.Resulted, res[id].Resulted)
+				require.Len(t, res[id].Outcomes, len(r.Outcomes))
+				for i, o := range r.Outcomes {
+					require.True(t, proto.Equal(o, res[id].Outcomes[i]), "outcome %d: %v", i, res[id].Outcomes[i])
+				}
+			}
+		})
+	}
+}
@@ -45,6 +45,49 @@ func TransformEvents(rawData []byte) ([]*pb.Event, error) {
 	return transformEvents(&root)
 }
 
+// SplitEvents returns the raw children of the events response, so that the events can be transformed one by one
+func SplitEvents(rawData []byte) ([]json.RawMessage, error) {
+	var root model.RawEventsRoot
+	if err := json.Unmarshal(rawData, &root); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecodeResponse, err)
+	}
+	return root.SSResponse.Children, nil
+}
+
+// TransformRawEvent transforms the raw child of the events response the same way TransformEvents does,
+// together with the id of the event class. It returns nil if the child is not a valid event
+func TransformRawEvent(rawData []byte) (*pb.Event, string, error) {
+	var c model.EventChild
+	if err := json.Unmarshal(rawData, &c); err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrDecodeResponse, err)
+	}
+	if !isEventValid(&c.Event) {
+		return nil, "", nil
+	}
+	ev, _, err := transformEvent(&c.Event)
+	if err != nil {
+		return nil, "", err
+	}
+	return ev, c.Event.ClassID, nil
+}
+
+// GetUnhandledMarkets returns the ids of the markets of the unhandled types together with the ids of their outcomes,
+// mapped by the ids of the events. The transform drops them on purpose, so they're never among the transformed markets
+func GetUnhandledMarkets(rawData []byte) (map[string]map[string]struct{}, error) {
+	var root model.EventsRoot
+	if err := json.Unmarshal(rawData, &root); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecodeResponse, err)
+	}
+	ums := make(map[string]map[string]struct{}, len(root.SSResponse.Children))
+	for _, c := range root.SSResponse.Children {
+		if !isEventValid(&c.Event) {
+			continue
+		}
+		ums[c.Event.ID] = getUnhandledMarketsIds(&c.Event)
+	}
+	return ums, nil
+}
+
 func TransformUpdates(rawData []byte) (*Update, error) {
 	if len(rawData) == 0 {
 		return nil, nil
@@ -116,7 +159,7 @@ func transformEvent(event *model.Event) (*pb.Event, map[string]struct{}, error)
 		return nil, nil, err
 	}
 
-	live, err := isLive(event.IsStarted)
+	live, err := isLive(event.IsStarted, event.RawIsOffCode)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1,6 +1,7 @@
 package transform_test
 
 import (
+	"bytes"
 	_ "embed"
 	"testing"
 	"time"
@@ -278,3 +279,79 @@ func getStringPtr(s string) *string {
 func getFloat64Ptr(f float64) *float64 {
 	return &f
 }
+
+func TestTransformRawEvent(t *testing.T) {
+	tc := []struct {
+		name    string
+		data    []byte
+		classes map[string]string
+	}{
+		{
+			name:    "empty start time",
+			data:    basketballEmptyStartTimeData,
+			classes: map[string]string{},
+		},
+		{
+			name: "too many participants",
+			data: basketballTooManyParticipantsData,
+		},
+		{
+			name:    "success",
+			data:    basketballSuccessData,
+			classes: map[string]string{"243810572": "25"},
+		},
+		{
+			name:    "success outright",
+			data:    basketballSuccessOutrightData,
+			classes: map[string]string{"241631428": "47"},
+		},
+	}
+
+	for _, tt := range tc {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			expectedEvs, expectedErr := transform.TransformEvents(tt.data)
+
+			raw, err := transform.SplitEvents(tt.data)
+			require.NoError(t, err)
+			var (
+				evs = make([]*pb.Event, 0)
+				cls = make(map[string]string)
+			)
+			for _, r := range raw {
+				ev, cl, err := transform.TransformRawEvent(r)
+				if err != nil {
+					require.Error(t, expectedErr)
+					require.EqualError(t, err, expectedErr.Error())
+					return
+				}
+				if ev == nil {
+					continue
+				}
+				evs = append(evs, ev)
+				cls[ev.ExternalId] = cl
+			}
+			require.NoError(t, expectedErr)
+			require.Equal(t, expectedEvs, evs)
+			require.Equal(t, tt.classes, cls)
+		})
+	}
+}
+
+func TestGetUnhandledMarkets(t *testing.T) {
+	ums, err := transform.GetUnhandledMarkets(basketballSuccessData)
+	require.NoError(t, err)
+	require.Equal(t, map[string]map[string]struct{}{"243810572": {}}, ums)
+
+	data := bytes.ReplaceAll(basketballSuccessData, []byte(`"templateMarketName": "Money Line"`), []byte(`"templateMarketName": "Unhandled"`))
+	ums, err = transform.GetUnhandledMarkets(data)
+	require.NoError(t, err)
+	require.Equal(t, map[string]map[string]struct{}{
+		"243810572": {"807508629": {}, "2281242410": {}, "2281242415": {}},
+	}, ums)
+
+	_, err = transform.GetUnhandledMarkets([]byte("{"))
+	require.ErrorIs(t, err, transform.ErrDecodeResponse)
+}
@@ -101,6 +101,20 @@ func getUpdateData(rawData []byte) []byte {
 	return rawData[updateDataStartIndex:]
 }
 
+// IsValidRequestBodyParts checks whether the parts can be used to build the next request body,
+// the stored ones might have been written by a different version of the protocol
+func IsValidRequestBodyParts(parts []string) bool {
+	if len(parts) != 2 {
+		return false
+	}
+	for _, p := range parts {
+		if len(p) != requestBodyPartLength {
+			return false
+		}
+	}
+	return true
+}
+
 func getRequestBodyParts(rawUpdates [][]byte) []string {
 	var (
 		first = rawUpdates[0][requestBodyPartsStartIndex : requestBodyPartsStartIndex+requestBodyPartLength]
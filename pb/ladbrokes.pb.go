@@ -0,0 +1,1041 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v4.24.4
+// source: ladbrokes.proto
+
+package pb
+
+import (
+	pb "github.com/olafszymanski/int-sdk/integration/pb"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Delta_DeltaType int32
+
+const (
+	Delta_EVENT_ADDED      Delta_DeltaType = 0
+	Delta_EVENT_REMOVED    Delta_DeltaType = 1
+	Delta_MARKET_ADDED     Delta_DeltaType = 2 // also used when outcomes were added to or removed from the market, the market replaces the previous one
+	Delta_MARKET_REMOVED   Delta_DeltaType = 3
+	Delta_MARKET_SUSPENDED Delta_DeltaType = 4
+	Delta_MARKET_RESUMED   Delta_DeltaType = 5
+	Delta_PRICE_CHANGED    Delta_DeltaType = 6
+)
+
+// Enum value maps for Delta_DeltaType.
+var (
+	Delta_DeltaType_name = map[int32]string{
+		0: "EVENT_ADDED",
+		1: "EVENT_REMOVED",
+		2: "MARKET_ADDED",
+		3: "MARKET_REMOVED",
+		4: "MARKET_SUSPENDED",
+		5: "MARKET_RESUMED",
+		6: "PRICE_CHANGED",
+	}
+	Delta_DeltaType_value = map[string]int32{
+		"EVENT_ADDED":      0,
+		"EVENT_REMOVED":    1,
+		"MARKET_ADDED":     2,
+		"MARKET_REMOVED":   3,
+		"MARKET_SUSPENDED": 4,
+		"MARKET_RESUMED":   5,
+		"PRICE_CHANGED":    6,
+	}
+)
+
+func (x Delta_DeltaType) Enum() *Delta_DeltaType {
+	p := new(Delta_DeltaType)
+	*p = x
+	return p
+}
+
+func (x Delta_DeltaType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Delta_DeltaType) Descriptor() protoreflect.EnumDescriptor {
+	return file_ladbrokes_proto_enumTypes[0].Descriptor()
+}
+
+func (Delta_DeltaType) Type() protoreflect.EnumType {
+	return &file_ladbrokes_proto_enumTypes[0]
+}
+
+func (x Delta_DeltaType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Delta_DeltaType.Descriptor instead.
+func (Delta_DeltaType) EnumDescriptor() ([]byte, []int) {
+	return file_ladbrokes_proto_rawDescGZIP(), []int{5, 0}
+}
+
+type EventRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ExternalId string       `protobuf:"bytes,1,opt,name=external_id,json=externalId,proto3" json:"external_id,omitempty"`
+	SportType  pb.SportType `protobuf:"varint,2,opt,name=sport_type,json=sportType,proto3,enum=SportType" json:"sport_type,omitempty"` // not needed to get the closing line
+}
+
+func (x *EventRequest) Reset() {
+	*x = EventRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ladbrokes_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventRequest) ProtoMessage() {}
+
+func (x *EventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ladbrokes_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventRequest.ProtoReflect.Descriptor instead.
+func (*EventRequest) Descriptor() ([]byte, []int) {
+	return file_ladbrokes_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EventRequest) GetExternalId() string {
+	if x != nil {
+		return x.ExternalId
+	}
+	return ""
+}
+
+func (x *EventRequest) GetSportType() pb.SportType {
+	if x != nil {
+		return x.SportType
+	}
+	return pb.SportType(0)
+}
+
+type ClosingLine struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Event      *pb.Event              `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"` // last pre-match state of the event, captured when it started
+	CapturedAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=captured_at,json=capturedAt,proto3" json:"captured_at,omitempty"`
+}
+
+func (x *ClosingLine) Reset() {
+	*x = ClosingLine{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ladbrokes_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClosingLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClosingLine) ProtoMessage() {}
+
+func (x *ClosingLine) ProtoReflect() protoreflect.Message {
+	mi := &file_ladbrokes_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClosingLine.ProtoReflect.Descriptor instead.
+func (*ClosingLine) Descriptor() ([]byte, []int) {
+	return file_ladbrokes_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ClosingLine) GetEvent() *pb.Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *ClosingLine) GetCapturedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CapturedAt
+	}
+	return nil
+}
+
+type OutcomeResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ExternalId       string `protobuf:"bytes,1,opt,name=external_id,json=externalId,proto3" json:"external_id,omitempty"`
+	MarketExternalId string `protobuf:"bytes,2,opt,name=market_external_id,json=marketExternalId,proto3" json:"market_external_id,omitempty"`
+	Result           string `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`                     // result code of the outcome, e.g. W (won), L (lost), V (void), P (placed), H (handicap push)
+	FbResult         string `protobuf:"bytes,4,opt,name=fb_result,json=fbResult,proto3" json:"fb_result,omitempty"` // result code of the outcome in a football market, e.g. H (home), D (draw), A (away)
+	Settled          bool   `protobuf:"varint,5,opt,name=settled,proto3" json:"settled,omitempty"`
+}
+
+func (x *OutcomeResult) Reset() {
+	*x = OutcomeResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ladbrokes_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OutcomeResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutcomeResult) ProtoMessage() {}
+
+func (x *OutcomeResult) ProtoReflect() protoreflect.Message {
+	mi := &file_ladbrokes_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutcomeResult.ProtoReflect.Descriptor instead.
+func (*OutcomeResult) Descriptor() ([]byte, []int) {
+	return file_ladbrokes_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OutcomeResult) GetExternalId() string {
+	if x != nil {
+		return x.ExternalId
+	}
+	return ""
+}
+
+func (x *OutcomeResult) GetMarketExternalId() string {
+	if x != nil {
+		return x.MarketExternalId
+	}
+	return ""
+}
+
+func (x *OutcomeResult) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *OutcomeResult) GetFbResult() string {
+	if x != nil {
+		return x.FbResult
+	}
+	return ""
+}
+
+func (x *OutcomeResult) GetSettled() bool {
+	if x != nil {
+		return x.Settled
+	}
+	return false
+}
+
+type Results struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	EventExternalId string                 `protobuf:"bytes,1,opt,name=event_external_id,json=eventExternalId,proto3" json:"event_external_id,omitempty"`
+	Outcomes        []*OutcomeResult       `protobuf:"bytes,2,rep,name=outcomes,proto3" json:"outcomes,omitempty"`
+	UpdatedAt       *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+}
+
+func (x *Results) Reset() {
+	*x = Results{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ladbrokes_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Results) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Results) ProtoMessage() {}
+
+func (x *Results) ProtoReflect() protoreflect.Message {
+	mi := &file_ladbrokes_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Results.ProtoReflect.Descriptor instead.
+func (*Results) Descriptor() ([]byte, []int) {
+	return file_ladbrokes_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Results) GetEventExternalId() string {
+	if x != nil {
+		return x.EventExternalId
+	}
+	return ""
+}
+
+func (x *Results) GetOutcomes() []*OutcomeResult {
+	if x != nil {
+		return x.Outcomes
+	}
+	return nil
+}
+
+func (x *Results) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type StreamRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SportType pb.SportType `protobuf:"varint,1,opt,name=sport_type,json=sportType,proto3,enum=SportType" json:"sport_type,omitempty"`
+	Live      bool         `protobuf:"varint,2,opt,name=live,proto3" json:"live,omitempty"`
+}
+
+func (x *StreamRequest) Reset() {
+	*x = StreamRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ladbrokes_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest) ProtoMessage() {}
+
+func (x *StreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ladbrokes_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest.ProtoReflect.Descriptor instead.
+func (*StreamRequest) Descriptor() ([]byte, []int) {
+	return file_ladbrokes_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *StreamRequest) GetSportType() pb.SportType {
+	if x != nil {
+		return x.SportType
+	}
+	return pb.SportType(0)
+}
+
+func (x *StreamRequest) GetLive() bool {
+	if x != nil {
+		return x.Live
+	}
+	return false
+}
+
+type Delta struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type             Delta_DeltaType `protobuf:"varint,1,opt,name=type,proto3,enum=ladbrokes.Delta_DeltaType" json:"type,omitempty"`
+	EventExternalId  string          `protobuf:"bytes,2,opt,name=event_external_id,json=eventExternalId,proto3" json:"event_external_id,omitempty"`
+	MarketExternalId *string         `protobuf:"bytes,3,opt,name=market_external_id,json=marketExternalId,proto3,oneof" json:"market_external_id,omitempty"` // used in case of a market or price delta
+	Event            *pb.Event       `protobuf:"bytes,4,opt,name=event,proto3" json:"event,omitempty"`                                                       // used in case type is EVENT_ADDED
+	Market           *pb.Market      `protobuf:"bytes,5,opt,name=market,proto3" json:"market,omitempty"`                                                     // used in case type is MARKET_ADDED
+	Outcome          *pb.Outcome     `protobuf:"bytes,6,opt,name=outcome,proto3" json:"outcome,omitempty"`                                                   // used in case type is PRICE_CHANGED
+}
+
+func (x *Delta) Reset() {
+	*x = Delta{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ladbrokes_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Delta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Delta) ProtoMessage() {}
+
+func (x *Delta) ProtoReflect() protoreflect.Message {
+	mi := &file_ladbrokes_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Delta.ProtoReflect.Descriptor instead.
+func (*Delta) Descriptor() ([]byte, []int) {
+	return file_ladbrokes_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Delta) GetType() Delta_DeltaType {
+	if x != nil {
+		return x.Type
+	}
+	return Delta_EVENT_ADDED
+}
+
+func (x *Delta) GetEventExternalId() string {
+	if x != nil {
+		return x.EventExternalId
+	}
+	return ""
+}
+
+func (x *Delta) GetMarketExternalId() string {
+	if x != nil && x.MarketExternalId != nil {
+		return *x.MarketExternalId
+	}
+	return ""
+}
+
+func (x *Delta) GetEvent() *pb.Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *Delta) GetMarket() *pb.Market {
+	if x != nil {
+		return x.Market
+	}
+	return nil
+}
+
+func (x *Delta) GetOutcome() *pb.Outcome {
+	if x != nil {
+		return x.Outcome
+	}
+	return nil
+}
+
+type Deltas struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Deltas []*Delta               `protobuf:"bytes,1,rep,name=deltas,proto3" json:"deltas,omitempty"`
+	Time   *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+}
+
+func (x *Deltas) Reset() {
+	*x = Deltas{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ladbrokes_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Deltas) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Deltas) ProtoMessage() {}
+
+func (x *Deltas) ProtoReflect() protoreflect.Message {
+	mi := &file_ladbrokes_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Deltas.ProtoReflect.Descriptor instead.
+func (*Deltas) Descriptor() ([]byte, []int) {
+	return file_ladbrokes_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Deltas) GetDeltas() []*Delta {
+	if x != nil {
+		return x.Deltas
+	}
+	return nil
+}
+
+func (x *Deltas) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+type StreamResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Snapshot []*pb.Event `protobuf:"bytes,1,rep,name=snapshot,proto3" json:"snapshot,omitempty"` // sent only in the first response, deltas published while it was read might be repeated afterwards
+	Deltas   []*Delta    `protobuf:"bytes,2,rep,name=deltas,proto3" json:"deltas,omitempty"`
+}
+
+func (x *StreamResponse) Reset() {
+	*x = StreamResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ladbrokes_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamResponse) ProtoMessage() {}
+
+func (x *StreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ladbrokes_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamResponse.ProtoReflect.Descriptor instead.
+func (*StreamResponse) Descriptor() ([]byte, []int) {
+	return file_ladbrokes_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StreamResponse) GetSnapshot() []*pb.Event {
+	if x != nil {
+		return x.Snapshot
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetDeltas() []*Delta {
+	if x != nil {
+		return x.Deltas
+	}
+	return nil
+}
+
+type QueryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SportType     pb.SportType           `protobuf:"varint,1,opt,name=sport_type,json=sportType,proto3,enum=SportType" json:"sport_type,omitempty"`
+	Live          bool                   `protobuf:"varint,2,opt,name=live,proto3" json:"live,omitempty"`
+	Leagues       []string               `protobuf:"bytes,3,rep,name=leagues,proto3" json:"leagues,omitempty"`
+	StartTimeFrom *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=start_time_from,json=startTimeFrom,proto3" json:"start_time_from,omitempty"` // inclusive
+	StartTimeTo   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=start_time_to,json=startTimeTo,proto3" json:"start_time_to,omitempty"`       // exclusive
+	ExternalIds   []string               `protobuf:"bytes,6,rep,name=external_ids,json=externalIds,proto3" json:"external_ids,omitempty"`
+	MarketTypes   []pb.MarketType        `protobuf:"varint,7,rep,packed,name=market_types,json=marketTypes,proto3,enum=MarketType" json:"market_types,omitempty"` // events are returned with the given market types only
+	MarketsOnly   bool                   `protobuf:"varint,8,opt,name=markets_only,json=marketsOnly,proto3" json:"markets_only,omitempty"`                        // returns only the external ids and markets of the events
+}
+
+func (x *QueryRequest) Reset() {
+	*x = QueryRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_ladbrokes_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *QueryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryRequest) ProtoMessage() {}
+
+func (x *QueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ladbrokes_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryRequest.ProtoReflect.Descriptor instead.
+func (*QueryRequest) Descriptor() ([]byte, []int) {
+	return file_ladbrokes_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *QueryRequest) GetSportType() pb.SportType {
+	if x != nil {
+		return x.SportType
+	}
+	return pb.SportType(0)
+}
+
+func (x *QueryRequest) GetLive() bool {
+	if x != nil {
+		return x.Live
+	}
+	return false
+}
+
+func (x *QueryRequest) GetLeagues() []string {
+	if x != nil {
+		return x.Leagues
+	}
+	return nil
+}
+
+func (x *QueryRequest) GetStartTimeFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartTimeFrom
+	}
+	return nil
+}
+
+func (x *QueryRequest) GetStartTimeTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartTimeTo
+	}
+	return nil
+}
+
+func (x *QueryRequest) GetExternalIds() []string {
+	if x != nil {
+		return x.ExternalIds
+	}
+	return nil
+}
+
+func (x *QueryRequest) GetMarketTypes() []pb.MarketType {
+	if x != nil {
+		return x.MarketTypes
+	}
+	return nil
+}
+
+func (x *QueryRequest) GetMarketsOnly() bool {
+	if x != nil {
+		return x.MarketsOnly
+	}
+	return false
+}
+
+var File_ladbrokes_proto protoreflect.FileDescriptor
+
+var file_ladbrokes_proto_rawDesc = []byte{
+	0x0a, 0x0f, 0x6c, 0x61, 0x64, 0x62, 0x72, 0x6f, 0x6b, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x09, 0x6c, 0x61, 0x64, 0x62, 0x72, 0x6f, 0x6b, 0x65, 0x73, 0x1a, 0x1b, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d,
+	0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x11, 0x69, 0x6e, 0x74, 0x65,
+	0x67, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x5a, 0x0a,
+	0x0c, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a,
+	0x0b, 0x65, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x65, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x29,
+	0x0a, 0x0a, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x0a, 0x2e, 0x53, 0x70, 0x6f, 0x72, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x09,
+	0x73, 0x70, 0x6f, 0x72, 0x74, 0x54, 0x79, 0x70, 0x65, 0x22, 0x68, 0x0a, 0x0b, 0x43, 0x6c, 0x6f,
+	0x73, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x6e, 0x65, 0x12, 0x1c, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x06, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52,
+	0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x3b, 0x0a, 0x0b, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72,
+	0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65,
+	0x64, 0x41, 0x74, 0x22, 0xad, 0x01, 0x0a, 0x0d, 0x4f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x65, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61,
+	0x6c, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x65, 0x78, 0x74, 0x65,
+	0x72, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x2c, 0x0a, 0x12, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x74,
+	0x5f, 0x65, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x10, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x74, 0x45, 0x78, 0x74, 0x65, 0x72, 0x6e,
+	0x61, 0x6c, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1b, 0x0a, 0x09,
+	0x66, 0x62, 0x5f, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x66, 0x62, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x74,
+	0x74, 0x6c, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x65, 0x74, 0x74,
+	0x6c, 0x65, 0x64, 0x22, 0xa6, 0x01, 0x0a, 0x07, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x12,
+	0x2a, 0x0a, 0x11, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x65, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61,
+	0x6c, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x45, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x34, 0x0a, 0x08, 0x6f,
+	0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e,
+	0x6c, 0x61, 0x64, 0x62, 0x72, 0x6f, 0x6b, 0x65, 0x73, 0x2e, 0x4f, 0x75, 0x74, 0x63, 0x6f, 0x6d,
+	0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x08, 0x6f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65,
+	0x73, 0x12, 0x39, 0x0a, 0x0a, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x22, 0x4e, 0x0a, 0x0d,
+	0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x29, 0x0a,
+	0x0a, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x0a, 0x2e, 0x53, 0x70, 0x6f, 0x72, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x09, 0x73,
+	0x70, 0x6f, 0x72, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x69, 0x76, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x6c, 0x69, 0x76, 0x65, 0x22, 0xa5, 0x03, 0x0a,
+	0x05, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x1a, 0x2e, 0x6c, 0x61, 0x64, 0x62, 0x72, 0x6f, 0x6b, 0x65, 0x73,
+	0x2e, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x2e, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x54, 0x79, 0x70, 0x65,
+	0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x2a, 0x0a, 0x11, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f,
+	0x65, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x45, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c,
+	0x49, 0x64, 0x12, 0x31, 0x0a, 0x12, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x74, 0x5f, 0x65, 0x78, 0x74,
+	0x65, 0x72, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00,
+	0x52, 0x10, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x74, 0x45, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c,
+	0x49, 0x64, 0x88, 0x01, 0x01, 0x12, 0x1c, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x06, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x05, 0x65, 0x76,
+	0x65, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x06, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x74, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x07, 0x2e, 0x4d, 0x61, 0x72, 0x6b, 0x65, 0x74, 0x52, 0x06, 0x6d, 0x61,
+	0x72, 0x6b, 0x65, 0x74, 0x12, 0x22, 0x0a, 0x07, 0x6f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x4f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x52,
+	0x07, 0x6f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x22, 0x92, 0x01, 0x0a, 0x09, 0x44, 0x65, 0x6c,
+	0x74, 0x61, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0f, 0x0a, 0x0b, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f,
+	0x41, 0x44, 0x44, 0x45, 0x44, 0x10, 0x00, 0x12, 0x11, 0x0a, 0x0d, 0x45, 0x56, 0x45, 0x4e, 0x54,
+	0x5f, 0x52, 0x45, 0x4d, 0x4f, 0x56, 0x45, 0x44, 0x10, 0x01, 0x12, 0x10, 0x0a, 0x0c, 0x4d, 0x41,
+	0x52, 0x4b, 0x45, 0x54, 0x5f, 0x41, 0x44, 0x44, 0x45, 0x44, 0x10, 0x02, 0x12, 0x12, 0x0a, 0x0e,
+	0x4d, 0x41, 0x52, 0x4b, 0x45, 0x54, 0x5f, 0x52, 0x45, 0x4d, 0x4f, 0x56, 0x45, 0x44, 0x10, 0x03,
+	0x12, 0x14, 0x0a, 0x10, 0x4d, 0x41, 0x52, 0x4b, 0x45, 0x54, 0x5f, 0x53, 0x55, 0x53, 0x50, 0x45,
+	0x4e, 0x44, 0x45, 0x44, 0x10, 0x04, 0x12, 0x12, 0x0a, 0x0e, 0x4d, 0x41, 0x52, 0x4b, 0x45, 0x54,
+	0x5f, 0x52, 0x45, 0x53, 0x55, 0x4d, 0x45, 0x44, 0x10, 0x05, 0x12, 0x11, 0x0a, 0x0d, 0x50, 0x52,
+	0x49, 0x43, 0x45, 0x5f, 0x43, 0x48, 0x41, 0x4e, 0x47, 0x45, 0x44, 0x10, 0x06, 0x42, 0x15, 0x0a,
+	0x13, 0x5f, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x74, 0x5f, 0x65, 0x78, 0x74, 0x65, 0x72, 0x6e, 0x61,
+	0x6c, 0x5f, 0x69, 0x64, 0x22, 0x62, 0x0a, 0x06, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x73, 0x12, 0x28,
+	0x0a, 0x06, 0x64, 0x65, 0x6c, 0x74, 0x61, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x6c, 0x61, 0x64, 0x62, 0x72, 0x6f, 0x6b, 0x65, 0x73, 0x2e, 0x44, 0x65, 0x6c, 0x74, 0x61,
+	0x52, 0x06, 0x64, 0x65, 0x6c, 0x74, 0x61, 0x73, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x22, 0x5e, 0x0a, 0x0e, 0x53, 0x74, 0x72, 0x65,
+	0x61, 0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x22, 0x0a, 0x08, 0x73, 0x6e,
+	0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x06, 0x2e, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x52, 0x08, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12, 0x28,
+	0x0a, 0x06, 0x64, 0x65, 0x6c, 0x74, 0x61, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x6c, 0x61, 0x64, 0x62, 0x72, 0x6f, 0x6b, 0x65, 0x73, 0x2e, 0x44, 0x65, 0x6c, 0x74, 0x61,
+	0x52, 0x06, 0x64, 0x65, 0x6c, 0x74, 0x61, 0x73, 0x22, 0xe1, 0x02, 0x0a, 0x0c, 0x51, 0x75, 0x65,
+	0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x29, 0x0a, 0x0a, 0x73, 0x70, 0x6f,
+	0x72, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0a, 0x2e,
+	0x53, 0x70, 0x6f, 0x72, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x09, 0x73, 0x70, 0x6f, 0x72, 0x74,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x69, 0x76, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x04, 0x6c, 0x69, 0x76, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6c, 0x65, 0x61, 0x67,
+	0x75, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x6c, 0x65, 0x61, 0x67, 0x75,
+	0x65, 0x73, 0x12, 0x42, 0x0a, 0x0f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65,
+	0x5f, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0d, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69,
+	0x6d, 0x65, 0x46, 0x72, 0x6f, 0x6d, 0x12, 0x3e, 0x0a, 0x0d, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f,
+	0x74, 0x69, 0x6d, 0x65, 0x5f, 0x74, 0x6f, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x73, 0x74, 0x61, 0x72, 0x74,
+	0x54, 0x69, 0x6d, 0x65, 0x54, 0x6f, 0x12, 0x21, 0x0a, 0x0c, 0x65, 0x78, 0x74, 0x65, 0x72, 0x6e,
+	0x61, 0x6c, 0x5f, 0x69, 0x64, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x65, 0x78,
+	0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x73, 0x12, 0x2e, 0x0a, 0x0c, 0x6d, 0x61, 0x72,
+	0x6b, 0x65, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0e, 0x32,
+	0x0b, 0x2e, 0x4d, 0x61, 0x72, 0x6b, 0x65, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0b, 0x6d, 0x61,
+	0x72, 0x6b, 0x65, 0x74, 0x54, 0x79, 0x70, 0x65, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x61, 0x72,
+	0x6b, 0x65, 0x74, 0x73, 0x5f, 0x6f, 0x6e, 0x6c, 0x79, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0b, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x74, 0x73, 0x4f, 0x6e, 0x6c, 0x79, 0x32, 0xfd, 0x02, 0x0a,
+	0x09, 0x4c, 0x61, 0x64, 0x62, 0x72, 0x6f, 0x6b, 0x65, 0x73, 0x12, 0x2d, 0x0a, 0x08, 0x47, 0x65,
+	0x74, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x17, 0x2e, 0x6c, 0x61, 0x64, 0x62, 0x72, 0x6f, 0x6b,
+	0x65, 0x73, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x06, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x22, 0x00, 0x12, 0x43, 0x0a, 0x0e, 0x47, 0x65, 0x74,
+	0x43, 0x6c, 0x6f, 0x73, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x6e, 0x65, 0x12, 0x17, 0x2e, 0x6c, 0x61,
+	0x64, 0x62, 0x72, 0x6f, 0x6b, 0x65, 0x73, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x6c, 0x61, 0x64, 0x62, 0x72, 0x6f, 0x6b, 0x65, 0x73,
+	0x2e, 0x43, 0x6c, 0x6f, 0x73, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x6e, 0x65, 0x22, 0x00, 0x12, 0x3b,
+	0x0a, 0x0a, 0x47, 0x65, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x12, 0x17, 0x2e, 0x6c,
+	0x61, 0x64, 0x62, 0x72, 0x6f, 0x6b, 0x65, 0x73, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x6c, 0x61, 0x64, 0x62, 0x72, 0x6f, 0x6b, 0x65,
+	0x73, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22, 0x00, 0x12, 0x47, 0x0a, 0x0c, 0x53,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x18, 0x2e, 0x6c, 0x61,
+	0x64, 0x62, 0x72, 0x6f, 0x6b, 0x65, 0x73, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x6c, 0x61, 0x64, 0x62, 0x72, 0x6f, 0x6b, 0x65,
+	0x73, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x00, 0x30, 0x01, 0x12, 0x33, 0x0a, 0x0b, 0x51, 0x75, 0x65, 0x72, 0x79, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x73, 0x12, 0x17, 0x2e, 0x6c, 0x61, 0x64, 0x62, 0x72, 0x6f, 0x6b, 0x65, 0x73, 0x2e,
+	0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x09, 0x2e, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x41, 0x0a, 0x0c, 0x52, 0x65, 0x66,
+	0x72, 0x65, 0x73, 0x68, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x17, 0x2e, 0x6c, 0x61, 0x64, 0x62,
+	0x72, 0x6f, 0x6b, 0x65, 0x73, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x42, 0x2b, 0x5a, 0x29,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6f, 0x6c, 0x61, 0x66, 0x73,
+	0x7a, 0x79, 0x6d, 0x61, 0x6e, 0x73, 0x6b, 0x69, 0x2f, 0x69, 0x6e, 0x74, 0x2d, 0x6c, 0x61, 0x64,
+	0x62, 0x72, 0x6f, 0x6b, 0x65, 0x73, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_ladbrokes_proto_rawDescOnce sync.Once
+	file_ladbrokes_proto_rawDescData = file_ladbrokes_proto_rawDesc
+)
+
+func file_ladbrokes_proto_rawDescGZIP() []byte {
+	file_ladbrokes_proto_rawDescOnce.Do(func() {
+		file_ladbrokes_proto_rawDescData = protoimpl.X.CompressGZIP(file_ladbrokes_proto_rawDescData)
+	})
+	return file_ladbrokes_proto_rawDescData
+}
+
+var file_ladbrokes_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_ladbrokes_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_ladbrokes_proto_goTypes = []interface{}{
+	(Delta_DeltaType)(0),          // 0: ladbrokes.Delta.DeltaType
+	(*EventRequest)(nil),          // 1: ladbrokes.EventRequest
+	(*ClosingLine)(nil),           // 2: ladbrokes.ClosingLine
+	(*OutcomeResult)(nil),         // 3: ladbrokes.OutcomeResult
+	(*Results)(nil),               // 4: ladbrokes.Results
+	(*StreamRequest)(nil),         // 5: ladbrokes.StreamRequest
+	(*Delta)(nil),                 // 6: ladbrokes.Delta
+	(*Deltas)(nil),                // 7: ladbrokes.Deltas
+	(*StreamResponse)(nil),        // 8: ladbrokes.StreamResponse
+	(*QueryRequest)(nil),          // 9: ladbrokes.QueryRequest
+	(pb.SportType)(0),             // 10: SportType
+	(*pb.Event)(nil),              // 11: Event
+	(*timestamppb.Timestamp)(nil), // 12: google.protobuf.Timestamp
+	(*pb.Market)(nil),             // 13: Market
+	(*pb.Outcome)(nil),            // 14: Outcome
+	(pb.MarketType)(0),            // 15: MarketType
+	(*pb.Response)(nil),           // 16: Response
+	(*emptypb.Empty)(nil),         // 17: google.protobuf.Empty
+}
+var file_ladbrokes_proto_depIdxs = []int32{
+	10, // 0: ladbrokes.EventRequest.sport_type:type_name -> SportType
+	11, // 1: ladbrokes.ClosingLine.event:type_name -> Event
+	12, // 2: ladbrokes.ClosingLine.captured_at:type_name -> google.protobuf.Timestamp
+	3,  // 3: ladbrokes.Results.outcomes:type_name -> ladbrokes.OutcomeResult
+	12, // 4: ladbrokes.Results.updated_at:type_name -> google.protobuf.Timestamp
+	10, // 5: ladbrokes.StreamRequest.sport_type:type_name -> SportType
+	0,  // 6: ladbrokes.Delta.type:type_name -> ladbrokes.Delta.DeltaType
+	11, // 7: ladbrokes.Delta.event:type_name -> Event
+	13, // 8: ladbrokes.Delta.market:type_name -> Market
+	14, // 9: ladbrokes.Delta.outcome:type_name -> Outcome
+	6,  // 10: ladbrokes.Deltas.deltas:type_name -> ladbrokes.Delta
+	12, // 11: ladbrokes.Deltas.time:type_name -> google.protobuf.Timestamp
+	11, // 12: ladbrokes.StreamResponse.snapshot:type_name -> Event
+	6,  // 13: ladbrokes.StreamResponse.deltas:type_name -> ladbrokes.Delta
+	10, // 14: ladbrokes.QueryRequest.sport_type:type_name -> SportType
+	12, // 15: ladbrokes.QueryRequest.start_time_from:type_name -> google.protobuf.Timestamp
+	12, // 16: ladbrokes.QueryRequest.start_time_to:type_name -> google.protobuf.Timestamp
+	15, // 17: ladbrokes.QueryRequest.market_types:type_name -> MarketType
+	1,  // 18: ladbrokes.Ladbrokes.GetEvent:input_type -> ladbrokes.EventRequest
+	1,  // 19: ladbrokes.Ladbrokes.GetClosingLine:input_type -> ladbrokes.EventRequest
+	1,  // 20: ladbrokes.Ladbrokes.GetResults:input_type -> ladbrokes.EventRequest
+	5,  // 21: ladbrokes.Ladbrokes.StreamEvents:input_type -> ladbrokes.StreamRequest
+	9,  // 22: ladbrokes.Ladbrokes.QueryEvents:input_type -> ladbrokes.QueryRequest
+	1,  // 23: ladbrokes.Ladbrokes.RefreshEvent:input_type -> ladbrokes.EventRequest
+	11, // 24: ladbrokes.Ladbrokes.GetEvent:output_type -> Event
+	2,  // 25: ladbrokes.Ladbrokes.GetClosingLine:output_type -> ladbrokes.ClosingLine
+	4,  // 26: ladbrokes.Ladbrokes.GetResults:output_type -> ladbrokes.Results
+	8,  // 27: ladbrokes.Ladbrokes.StreamEvents:output_type -> ladbrokes.StreamResponse
+	16, // 28: ladbrokes.Ladbrokes.QueryEvents:output_type -> Response
+	17, // 29: ladbrokes.Ladbrokes.RefreshEvent:output_type -> google.protobuf.Empty
+	24, // [24:30] is the sub-list for method output_type
+	18, // [18:24] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
+}
+
+func init() { file_ladbrokes_proto_init() }
+func file_ladbrokes_proto_init() {
+	if File_ladbrokes_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_ladbrokes_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EventRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ladbrokes_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ClosingLine); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ladbrokes_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OutcomeResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ladbrokes_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Results); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ladbrokes_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ladbrokes_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Delta); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ladbrokes_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Deltas); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ladbrokes_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_ladbrokes_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*QueryRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_ladbrokes_proto_msgTypes[5].OneofWrappers = []interface{}{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_ladbrokes_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ladbrokes_proto_goTypes,
+		DependencyIndexes: file_ladbrokes_proto_depIdxs,
+		EnumInfos:         file_ladbrokes_proto_enumTypes,
+		MessageInfos:      file_ladbrokes_proto_msgTypes,
+	}.Build()
+	File_ladbrokes_proto = out.File
+	file_ladbrokes_proto_rawDesc = nil
+	file_ladbrokes_proto_goTypes = nil
+	file_ladbrokes_proto_depIdxs = nil
+}
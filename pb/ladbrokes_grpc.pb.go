@@ -0,0 +1,317 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v4.24.4
+// source: ladbrokes.proto
+
+package pb
+
+import (
+	context "context"
+	pb "github.com/olafszymanski/int-sdk/integration/pb"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// LadbrokesClient is the client API for Ladbrokes service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LadbrokesClient interface {
+	GetEvent(ctx context.Context, in *EventRequest, opts ...grpc.CallOption) (*pb.Event, error)
+	GetClosingLine(ctx context.Context, in *EventRequest, opts ...grpc.CallOption) (*ClosingLine, error)
+	GetResults(ctx context.Context, in *EventRequest, opts ...grpc.CallOption) (*Results, error)
+	StreamEvents(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (Ladbrokes_StreamEventsClient, error)
+	QueryEvents(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*pb.Response, error)
+	// refreshes the live event from its full snapshot, it's an admin method
+	RefreshEvent(ctx context.Context, in *EventRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type ladbrokesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLadbrokesClient(cc grpc.ClientConnInterface) LadbrokesClient {
+	return &ladbrokesClient{cc}
+}
+
+func (c *ladbrokesClient) GetEvent(ctx context.Context, in *EventRequest, opts ...grpc.CallOption) (*pb.Event, error) {
+	out := new(pb.Event)
+	err := c.cc.Invoke(ctx, "/ladbrokes.Ladbrokes/GetEvent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ladbrokesClient) GetClosingLine(ctx context.Context, in *EventRequest, opts ...grpc.CallOption) (*ClosingLine, error) {
+	out := new(ClosingLine)
+	err := c.cc.Invoke(ctx, "/ladbrokes.Ladbrokes/GetClosingLine", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ladbrokesClient) GetResults(ctx context.Context, in *EventRequest, opts ...grpc.CallOption) (*Results, error) {
+	out := new(Results)
+	err := c.cc.Invoke(ctx, "/ladbrokes.Ladbrokes/GetResults", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ladbrokesClient) StreamEvents(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (Ladbrokes_StreamEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Ladbrokes_ServiceDesc.Streams[0], "/ladbrokes.Ladbrokes/StreamEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ladbrokesStreamEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Ladbrokes_StreamEventsClient interface {
+	Recv() (*StreamResponse, error)
+	grpc.ClientStream
+}
+
+type ladbrokesStreamEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *ladbrokesStreamEventsClient) Recv() (*StreamResponse, error) {
+	m := new(StreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ladbrokesClient) QueryEvents(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*pb.Response, error) {
+	out := new(pb.Response)
+	err := c.cc.Invoke(ctx, "/ladbrokes.Ladbrokes/QueryEvents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ladbrokesClient) RefreshEvent(ctx context.Context, in *EventRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/ladbrokes.Ladbrokes/RefreshEvent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LadbrokesServer is the server API for Ladbrokes service.
+// All implementations must embed UnimplementedLadbrokesServer
+// for forward compatibility
+type LadbrokesServer interface {
+	GetEvent(context.Context, *EventRequest) (*pb.Event, error)
+	GetClosingLine(context.Context, *EventRequest) (*ClosingLine, error)
+	GetResults(context.Context, *EventRequest) (*Results, error)
+	StreamEvents(*StreamRequest, Ladbrokes_StreamEventsServer) error
+	QueryEvents(context.Context, *QueryRequest) (*pb.Response, error)
+	// refreshes the live event from its full snapshot, it's an admin method
+	RefreshEvent(context.Context, *EventRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedLadbrokesServer()
+}
+
+// UnimplementedLadbrokesServer must be embedded to have forward compatible implementations.
+type UnimplementedLadbrokesServer struct {
+}
+
+func (UnimplementedLadbrokesServer) GetEvent(context.Context, *EventRequest) (*pb.Event, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEvent not implemented")
+}
+func (UnimplementedLadbrokesServer) GetClosingLine(context.Context, *EventRequest) (*ClosingLine, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetClosingLine not implemented")
+}
+func (UnimplementedLadbrokesServer) GetResults(context.Context, *EventRequest) (*Results, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetResults not implemented")
+}
+func (UnimplementedLadbrokesServer) StreamEvents(*StreamRequest, Ladbrokes_StreamEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedLadbrokesServer) QueryEvents(context.Context, *QueryRequest) (*pb.Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryEvents not implemented")
+}
+func (UnimplementedLadbrokesServer) RefreshEvent(context.Context, *EventRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefreshEvent not implemented")
+}
+func (UnimplementedLadbrokesServer) mustEmbedUnimplementedLadbrokesServer() {}
+
+// UnsafeLadbrokesServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LadbrokesServer will
+// result in compilation errors.
+type UnsafeLadbrokesServer interface {
+	mustEmbedUnimplementedLadbrokesServer()
+}
+
+func RegisterLadbrokesServer(s grpc.ServiceRegistrar, srv LadbrokesServer) {
+	s.RegisterService(&Ladbrokes_ServiceDesc, srv)
+}
+
+func _Ladbrokes_GetEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LadbrokesServer).GetEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ladbrokes.Ladbrokes/GetEvent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LadbrokesServer).GetEvent(ctx, req.(*EventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ladbrokes_GetClosingLine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LadbrokesServer).GetClosingLine(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ladbrokes.Ladbrokes/GetClosingLine",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LadbrokesServer).GetClosingLine(ctx, req.(*EventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ladbrokes_GetResults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LadbrokesServer).GetResults(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ladbrokes.Ladbrokes/GetResults",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LadbrokesServer).GetResults(ctx, req.(*EventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ladbrokes_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LadbrokesServer).StreamEvents(m, &ladbrokesStreamEventsServer{stream})
+}
+
+type Ladbrokes_StreamEventsServer interface {
+	Send(*StreamResponse) error
+	grpc.ServerStream
+}
+
+type ladbrokesStreamEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *ladbrokesStreamEventsServer) Send(m *StreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Ladbrokes_QueryEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LadbrokesServer).QueryEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ladbrokes.Ladbrokes/QueryEvents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LadbrokesServer).QueryEvents(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ladbrokes_RefreshEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LadbrokesServer).RefreshEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ladbrokes.Ladbrokes/RefreshEvent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LadbrokesServer).RefreshEvent(ctx, req.(*EventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Ladbrokes_ServiceDesc is the grpc.ServiceDesc for Ladbrokes service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Ladbrokes_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ladbrokes.Ladbrokes",
+	HandlerType: (*LadbrokesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetEvent",
+			Handler:    _Ladbrokes_GetEvent_Handler,
+		},
+		{
+			MethodName: "GetClosingLine",
+			Handler:    _Ladbrokes_GetClosingLine_Handler,
+		},
+		{
+			MethodName: "GetResults",
+			Handler:    _Ladbrokes_GetResults_Handler,
+		},
+		{
+			MethodName: "QueryEvents",
+			Handler:    _Ladbrokes_QueryEvents_Handler,
+		},
+		{
+			MethodName: "RefreshEvent",
+			Handler:    _Ladbrokes_RefreshEvent_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _Ladbrokes_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ladbrokes.proto",
+}